@@ -0,0 +1,157 @@
+package archivedeliveries
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-organization-webhooks/internal/archive"
+	"github.com/katiem0/gh-organization-webhooks/internal/client"
+	"github.com/katiem0/gh-organization-webhooks/internal/config"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type archiveCmdFlags struct {
+	token          string
+	hostname       string
+	appID          int64
+	appPrivateKey  string
+	installationID int64
+	outputDir      string
+	hookIDs        []int64
+	timeout        time.Duration
+	requestTimeout time.Duration
+	debug          bool
+}
+
+type deliveryGetter interface {
+	GetOrganizationWebhooks(ctx context.Context, owner string) ([]byte, error)
+	GetHookDelivery(ctx context.Context, owner string, hookID, deliveryID int64) ([]byte, error)
+	GetHookDeliveries(ctx context.Context, owner string, hookID int64, cursor string) ([]byte, string, error)
+}
+
+func NewCmdArchiveDeliveries() *cobra.Command {
+	archiveCmdFlags := archiveCmdFlags{}
+
+	archiveCmd := &cobra.Command{
+		Use:   "archive-deliveries [<organization>] [flags]",
+		Short: "Archive organization webhook deliveries locally",
+		Long:  "Download the full request and response of organization webhook deliveries made since the last run into a compressed, date-partitioned local archive with an index file",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(archiveCmd *cobra.Command, args []string) error {
+			var err error
+			var restClient *api.RESTClient
+
+			// Reinitialize logging if debugging was enabled
+			if archiveCmdFlags.debug {
+				logger, _ := log.NewLogger(archiveCmdFlags.debug)
+				defer logger.Sync() // nolint:errcheck
+				zap.ReplaceGlobals(logger)
+			}
+
+			restClient, err = client.NewRESTClient(client.Options{
+				Hostname:       archiveCmdFlags.hostname,
+				Token:          archiveCmdFlags.token,
+				AppID:          archiveCmdFlags.appID,
+				AppPrivateKey:  archiveCmdFlags.appPrivateKey,
+				InstallationID: archiveCmdFlags.installationID,
+				RequestTimeout: archiveCmdFlags.requestTimeout,
+			})
+			if err != nil {
+				zap.S().Errorf("Error arose retrieving rest client: %v", err)
+				return err
+			}
+
+			a, err := archive.Open(archiveCmdFlags.outputDir)
+			if err != nil {
+				zap.S().Errorf("Error opening archive %s: %v", archiveCmdFlags.outputDir, err)
+				return err
+			}
+			defer func() {
+				if err := a.Close(); err != nil {
+					zap.S().Errorf("Error closing archive index: %v", err)
+				}
+			}()
+
+			ctx, cancel := client.CommandContext(archiveCmdFlags.timeout)
+			defer cancel()
+
+			owner, err := config.FromContext(archiveCmd.Context()).OrganizationArg(args, 0)
+			if err != nil {
+				return err
+			}
+
+			return runCmdArchiveDeliveries(ctx, owner, data.NewAPIGetter(restClient), a, archiveCmdFlags.hookIDs, os.Stdout)
+		},
+	}
+
+	// Configure flags for command
+	archiveCmd.PersistentFlags().StringVarP(&archiveCmdFlags.token, "token", "t", "", `GitHub personal access token for reading the organization (default "gh auth token")`)
+	archiveCmd.PersistentFlags().StringVarP(&archiveCmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
+	archiveCmd.PersistentFlags().Int64VarP(&archiveCmdFlags.appID, "app-id", "", 0, "GitHub App ID used to authenticate instead of a token (Requires --app-private-key and --installation-id)")
+	archiveCmd.PersistentFlags().StringVarP(&archiveCmdFlags.appPrivateKey, "app-private-key", "", "", "Path to the GitHub App private key PEM file")
+	archiveCmd.PersistentFlags().Int64VarP(&archiveCmdFlags.installationID, "installation-id", "", 0, "GitHub App installation ID for the organization")
+	archiveCmd.Flags().StringVarP(&archiveCmdFlags.outputDir, "output-dir", "o", "webhook-deliveries", "Directory of the delivery archive")
+	archiveCmd.Flags().Int64SliceVarP(&archiveCmdFlags.hookIDs, "hook-id", "", nil, "Only archive deliveries of these hook IDs (default all hooks)")
+	client.AddTimeoutFlags(archiveCmd.PersistentFlags(), &archiveCmdFlags.timeout, &archiveCmdFlags.requestTimeout)
+	archiveCmd.PersistentFlags().BoolVarP(&archiveCmdFlags.debug, "debug", "d", false, "To debug logging")
+
+	return archiveCmd
+}
+
+func runCmdArchiveDeliveries(ctx context.Context, owner string, g deliveryGetter, a *archive.Archive, hookIDs []int64, out io.Writer) error {
+	if len(hookIDs) == 0 {
+		zap.S().Debugf("Gathering Webhooks for %s", owner)
+		orgWebhooks, err := g.GetOrganizationWebhooks(ctx, owner)
+		if err != nil {
+			zap.S().Errorf("Error authenticating and getting response from webhooks endpoint for %v", owner)
+			return err
+		}
+		var responseWebhooks []data.Webhook
+		if err := json.Unmarshal(orgWebhooks, &responseWebhooks); err != nil {
+			return err
+		}
+		for _, hook := range responseWebhooks {
+			hookIDs = append(hookIDs, int64(hook.ID))
+		}
+	}
+
+	total := 0
+	for _, hookID := range hookIDs {
+		lastID := a.LastDeliveryID(owner, hookID)
+		deliveries, err := data.DeliveriesAfter(ctx, g, owner, hookID, lastID, 0)
+		if err != nil {
+			zap.S().Errorf("Error listing deliveries of hook %d for %s", hookID, owner)
+			return err
+		}
+		zap.S().Debugf("Found %d new deliveries for hook %d after delivery %d", len(deliveries), hookID, lastID)
+
+		for _, d := range deliveries {
+			raw, err := g.GetHookDelivery(ctx, owner, hookID, d.ID)
+			if err != nil {
+				zap.S().Errorf("Error getting delivery %d of hook %d for %s", d.ID, hookID, owner)
+				return err
+			}
+			var detail data.HookDelivery
+			if err := json.Unmarshal(raw, &detail); err != nil {
+				return err
+			}
+			entry, err := a.Add(owner, hookID, detail, raw)
+			if err != nil {
+				return err
+			}
+			zap.S().Debugf("Archived delivery %d to %s", d.ID, entry.Path)
+		}
+		fmt.Fprintf(out, "Archived %d new deliveries of hook %d\n", len(deliveries), hookID)
+		total += len(deliveries)
+	}
+	fmt.Fprintf(out, "Archived %d new deliveries for %s to %s\n", total, owner, a.Dir)
+	return nil
+}
@@ -0,0 +1,96 @@
+package archivedeliveries
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/archive"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+)
+
+func TestNewCmdArchiveDeliveries(t *testing.T) {
+	cmd := NewCmdArchiveDeliveries()
+
+	if cmd == nil {
+		t.Fatal("NewCmdArchiveDeliveries() returned nil")
+	}
+
+	if cmd.Use != "archive-deliveries [<organization>] [flags]" {
+		t.Errorf("Expected Use to be 'archive-deliveries [<organization>] [flags]', got %s", cmd.Use)
+	}
+
+	for _, name := range []string{"token", "hostname", "output-dir", "hook-id", "debug"} {
+		if cmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+}
+
+// deliveryMock extends the shared mock with per-delivery detail responses
+type deliveryMock struct {
+	*data.MockAPIGetter
+}
+
+func (m deliveryMock) GetHookDelivery(ctx context.Context, owner string, hookID, deliveryID int64) ([]byte, error) {
+	return []byte(fmt.Sprintf(`{"id":%d,"event":"push","delivered_at":"2024-05-01T10:00:00Z","request":{"headers":{},"payload":{}}}`, deliveryID)), nil
+}
+
+func TestRunCmdArchiveDeliveries(t *testing.T) {
+	dir := t.TempDir()
+	mock := deliveryMock{data.NewMockAPIGetter()}
+	mock.OrganizationWebhooksData = []byte(`[{"id":7}]`)
+	mock.HookDeliveriesData = []byte(`[{"id":2},{"id":1}]`)
+
+	a, err := archive.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := runCmdArchiveDeliveries(context.Background(), "test-org", mock, a, nil, &buf); err != nil {
+		t.Fatalf("runCmdArchiveDeliveries() error = %v", err)
+	}
+	_ = a.Close()
+	if !strings.Contains(buf.String(), "Archived 2 new deliveries for test-org") {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+
+	// A second run only archives deliveries made since the first
+	mock.HookDeliveriesData = []byte(`[{"id":3},{"id":2},{"id":1}]`)
+	a, err = archive.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := runCmdArchiveDeliveries(context.Background(), "test-org", mock, a, nil, &buf); err != nil {
+		t.Fatalf("runCmdArchiveDeliveries() error = %v", err)
+	}
+	_ = a.Close()
+
+	entries, err := archive.ReadIndex(dir)
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("Expected 3 index entries, got %+v (%v)", entries, err)
+	}
+	for i, e := range entries {
+		if e.DeliveryID != int64(i+1) || e.Path != fmt.Sprintf("2024/05/01/7-%d.json.gz", i+1) {
+			t.Errorf("Unexpected entry %+v", e)
+		}
+	}
+}
+
+func TestRunCmdArchiveDeliveriesError(t *testing.T) {
+	mock := data.NewMockAPIGetter()
+	mock.MethodErrors = map[string]error{"GetHookDeliveries": errors.New("forbidden")}
+
+	a, err := archive.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close() // nolint:errcheck
+	if err := runCmdArchiveDeliveries(context.Background(), "test-org", mock, a, []int64{7}, &bytes.Buffer{}); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
@@ -2,48 +2,86 @@ package create
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cli/go-gh/v2/pkg/api"
-	"github.com/cli/go-gh/v2/pkg/auth"
+	"github.com/katiem0/gh-organization-webhooks/internal/client"
+	"github.com/katiem0/gh-organization-webhooks/internal/config"
 	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/journal"
 	"github.com/katiem0/gh-organization-webhooks/internal/log"
+	"github.com/katiem0/gh-organization-webhooks/internal/output"
+	"github.com/katiem0/gh-organization-webhooks/internal/policy"
+	"github.com/katiem0/gh-organization-webhooks/internal/preflight"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
 type cmdFlags struct {
-	sourceToken    string
-	sourceOrg      string
-	sourceHostname string
-	token          string
-	hostname       string
-	fileName       string
-	debug          bool
+	sourceToken          string
+	sourceOrg            string
+	sourceHostname       string
+	sourceAppID          int64
+	sourceAppPrivateKey  string
+	sourceInstallationID int64
+	token                string
+	hostname             string
+	appID                int64
+	appPrivateKey        string
+	installationID       int64
+	fileName             string
+	skipPreflight        bool
+	allowedHosts         []string
+	policyFile           string
+	force                bool
+	failFast             bool
+	failedFile           string
+	reportFile           string
+	journalFile          string
+	checkpointFile       string
+	resume               string
+	writeRate            float64
+	writeSpacing         time.Duration
+	writeConcurrency     int
+	timeout              time.Duration
+	requestTimeout       time.Duration
+	debug                bool
 }
 
 func NewCmdCreate() *cobra.Command {
 	cmdFlags := cmdFlags{}
-	var authToken string
 
 	cmd := &cobra.Command{
-		Use:   "create <target organization> [flags]",
+		Use:   "create [<target organization>] [flags]",
 		Short: "Create organization level webhooks",
 		Long:  "Create organization level webhooks",
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MaximumNArgs(1),
 		PreRunE: func(createCmd *cobra.Command, args []string) error {
 			if len(cmdFlags.fileName) == 0 && len(cmdFlags.sourceOrg) == 0 {
 				return errors.New("a file or source organization must be specified where webhooks will be created from")
-			} else if len(cmdFlags.sourceOrg) > 0 && len(cmdFlags.sourceToken) == 0 {
-				return errors.New("a Personal Access Token must be specified to access webhooks from the Source Organization")
+			} else if len(cmdFlags.sourceOrg) > 0 && !cmdFlags.sourceClientOptions().HasCredentials() {
+				return errors.New("a Personal Access Token or GitHub App must be specified to access webhooks from the Source Organization")
 			} else if len(cmdFlags.fileName) > 0 && len(cmdFlags.sourceOrg) > 0 {
 				return errors.New("specify only one of `--source-organization` or `from-file`")
 			}
-			return nil
+			if err := cmdFlags.clientOptions().Validate(); err != nil {
+				return err
+			}
+			return cmdFlags.sourceClientOptions().Validate()
 		},
 		RunE: func(createCmd *cobra.Command, args []string) error {
 			var err error
@@ -56,46 +94,109 @@ func NewCmdCreate() *cobra.Command {
 				zap.ReplaceGlobals(logger)
 			}
 
-			if cmdFlags.token != "" {
-				authToken = cmdFlags.token
-			} else {
-				t, _ := auth.TokenForHost(cmdFlags.hostname)
-				authToken = t
-			}
-
-			restClient, err = api.NewRESTClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github+json",
-				},
-				Host:      cmdFlags.hostname,
-				AuthToken: authToken,
-			})
+			restClient, err = client.NewRESTClient(cmdFlags.clientOptions())
 
 			if err != nil {
 				zap.S().Errorf("Error arose retrieving rest client")
 				return err
 			}
 
-			owner := args[0]
+			ctx, cancel := client.WithTimeout(context.Background(), cmdFlags.timeout)
+			defer cancel()
 
-			return runCmdCreate(owner, &cmdFlags, data.NewAPIGetter(restClient))
+			owner, err := config.FromContext(createCmd.Context()).OrganizationArg(args, 0)
+			if err != nil {
+				return err
+			}
+
+			return runCmdCreate(ctx, owner, &cmdFlags, config.FromContext(createCmd.Context()), data.NewAPIGetter(restClient))
 		},
 	}
+	failedFileDefault := fmt.Sprintf("FailedWebhooks-%s.csv", time.Now().Format("20060102150405"))
+	journalFileDefault := fmt.Sprintf("WebhookJournal-%s.jsonl", time.Now().Format("20060102150405"))
+
 	// Configure flags for command
 	cmd.PersistentFlags().StringVarP(&cmdFlags.token, "token", "t", "", `GitHub personal access token for organization to write to (default "gh auth token")`)
 	cmd.PersistentFlags().StringVarP(&cmdFlags.sourceToken, "source-token", "s", "", `GitHub personal access token for Source Organization (Required for --source-organization)`)
 	cmd.PersistentFlags().StringVarP(&cmdFlags.sourceOrg, "source-organization", "o", "", `Name of the Source Organization to copy webhooks from (Requires --source-token)`)
 	cmd.PersistentFlags().StringVarP(&cmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
 	cmd.PersistentFlags().StringVarP(&cmdFlags.sourceHostname, "source-hostname", "", "github.com", "GitHub Enterprise Server hostname where webhooks are copied from")
+	cmd.PersistentFlags().Int64VarP(&cmdFlags.appID, "app-id", "", 0, "GitHub App ID used to authenticate to the organization to write to (Requires --app-private-key and --installation-id)")
+	cmd.PersistentFlags().StringVarP(&cmdFlags.appPrivateKey, "app-private-key", "", "", "Path to the GitHub App private key PEM file for the organization to write to")
+	cmd.PersistentFlags().Int64VarP(&cmdFlags.installationID, "installation-id", "", 0, "GitHub App installation ID for the organization to write to")
+	cmd.PersistentFlags().Int64VarP(&cmdFlags.sourceAppID, "source-app-id", "", 0, "GitHub App ID used to authenticate to the Source Organization (Requires --source-app-private-key and --source-installation-id)")
+	cmd.PersistentFlags().StringVarP(&cmdFlags.sourceAppPrivateKey, "source-app-private-key", "", "", "Path to the GitHub App private key PEM file for the Source Organization")
+	cmd.PersistentFlags().Int64VarP(&cmdFlags.sourceInstallationID, "source-installation-id", "", 0, "GitHub App installation ID for the Source Organization")
 	cmd.Flags().StringVarP(&cmdFlags.fileName, "from-file", "f", "", "Path and Name of CSV file to create webhooks from")
+	cmd.Flags().StringSliceVarP(&cmdFlags.allowedHosts, "allowed-host", "", nil, "Host webhooks may deliver to, where *.example.com allows subdomains (repeatable)")
+	cmd.Flags().StringVarP(&cmdFlags.policyFile, "policy-file", "p", "", "Path and Name of a YAML policy file whose allowed_domains are added to the allowed hosts")
+	cmd.Flags().BoolVarP(&cmdFlags.force, "force", "", false, "Create webhooks that use plain HTTP, insecure SSL or hosts outside the allowed hosts")
+	cmd.Flags().BoolVarP(&cmdFlags.failFast, "fail-fast", "", false, "Stop at the first webhook that fails to be created")
+	cmd.Flags().StringVarP(&cmdFlags.failedFile, "failed-file", "", failedFileDefault, "Name of CSV file to write webhooks that were not created to, with the reason, so they can be retried with --from-file")
+	cmd.Flags().StringVarP(&cmdFlags.reportFile, "report-file", "", "", "Name of file to write a JSON report of the run to, including the ID of each created webhook")
+	cmd.Flags().StringVarP(&cmdFlags.journalFile, "journal", "", journalFileDefault, "Name of file to record created webhooks in, for the rollback command (empty to disable)")
+	cmd.Flags().StringVarP(&cmdFlags.checkpointFile, "checkpoint", "", "", `File recording the webhooks created so far, removed when the run completes and kept when it fails (default ".create-<target organization>.json")`)
+	cmd.Flags().StringVarP(&cmdFlags.resume, "resume", "", "", "Continue an interrupted run from its checkpoint file, skipping the webhooks it already created and reusing the secrets entered for it")
+	cmd.Flags().Float64VarP(&cmdFlags.writeRate, "write-rate", "", client.DefaultWriteRate, "Maximum webhook writes per second across organizations (0 for no limit)")
+	cmd.Flags().DurationVarP(&cmdFlags.writeSpacing, "write-spacing", "", 0, "Minimum time between webhook writes to the same organization")
+	cmd.Flags().IntVarP(&cmdFlags.writeConcurrency, "write-concurrency", "", 1, "Maximum webhook writes in flight per organization")
+	cmd.Flags().BoolVarP(&cmdFlags.skipPreflight, "skip-preflight", "", false, "Skip checking token scopes, membership and webhook limits before creating webhooks")
+	cmd.PersistentFlags().DurationVarP(&cmdFlags.timeout, "timeout", "", 0, "Maximum time for the whole command, after which no further webhooks are created (0 for no limit)")
+	client.AddRequestTimeoutFlag(cmd.PersistentFlags(), &cmdFlags.requestTimeout)
 	cmd.PersistentFlags().BoolVarP(&cmdFlags.debug, "debug", "d", false, "To debug logging")
 
 	return cmd
 }
 
-func runCmdCreate(owner string, cmdFlags *cmdFlags, g *data.APIGetter) error {
+func (f *cmdFlags) clientOptions() client.Options {
+	return client.Options{
+		Hostname:       f.hostname,
+		Token:          f.token,
+		AppID:          f.appID,
+		AppPrivateKey:  f.appPrivateKey,
+		InstallationID: f.installationID,
+		RequestTimeout: f.requestTimeout,
+		WritePacing: client.WritePacing{
+			Rate:        f.writeRate,
+			Spacing:     f.writeSpacing,
+			Concurrency: f.writeConcurrency,
+		},
+	}
+}
+
+func (f *cmdFlags) sourceClientOptions() client.Options {
+	return client.Options{
+		Hostname:       f.sourceHostname,
+		Token:          f.sourceToken,
+		AppID:          f.sourceAppID,
+		AppPrivateKey:  f.sourceAppPrivateKey,
+		InstallationID: f.sourceInstallationID,
+		RequestTimeout: f.requestTimeout,
+	}
+}
+
+// webhookCreator is the subset of the API used to create webhooks
+type webhookCreator interface {
+	CreateOrganizationWebhook(ctx context.Context, owner string, data io.Reader) ([]byte, error)
+}
+
+func (f *cmdFlags) guard() (policy.Guard, error) {
+	guard := policy.Guard{AllowedDomains: f.allowedHosts}
+	if f.policyFile != "" {
+		p, err := policy.Load(f.policyFile)
+		if err != nil {
+			return guard, err
+		}
+		guard.AllowedDomains = append(guard.AllowedDomains, p.AllowedDomains...)
+	}
+	return guard, nil
+}
+
+func runCmdCreate(ctx context.Context, owner string, cmdFlags *cmdFlags, profile config.Profile, g *data.APIGetter) error {
+	started := time.Now()
 	var webhookData [][]string
 	var webhooksList []data.CreatedWebhook
+	var rows [][]string
 	if len(cmdFlags.fileName) > 0 {
 		f, err := os.Open(cmdFlags.fileName)
 		zap.S().Debugf("Opening up file %s", cmdFlags.fileName)
@@ -116,51 +217,418 @@ func runCmdCreate(owner string, cmdFlags *cmdFlags, g *data.APIGetter) error {
 			zap.S().Errorf("Error arose reading webhooks from csv file")
 		}
 		webhooksList = g.CreateWebhookList(webhookData)
+		rows = webhookData[1:]
 		zap.S().Debugf("Identifying Webhook list to create under %s", owner)
 	} else if len(cmdFlags.sourceOrg) > 0 {
 		zap.S().Debugf("Reading in webhooks from %s", cmdFlags.sourceOrg)
-		var authToken string
-		var restSourceClient *api.RESTClient
-
-		if cmdFlags.sourceToken != "" {
-			authToken = cmdFlags.sourceToken
-		} else {
-			t, _ := auth.TokenForHost(cmdFlags.sourceHostname)
-			authToken = t
-		}
-
-		restSourceClient, err := api.NewRESTClient(api.ClientOptions{
-			Headers: map[string]string{
-				"Accept": "application/vnd.github+json",
-			},
-			Host:      cmdFlags.sourceHostname,
-			AuthToken: authToken,
-		})
+		restSourceClient, err := client.NewRESTClient(cmdFlags.sourceClientOptions())
 		if err != nil {
 			zap.S().Errorf("Error arose retrieving source rest client")
 			return err
 		}
 		zap.S().Debugf("Gathering webhooks %s", cmdFlags.sourceOrg)
 
-		webhookResponse, err := data.GetSourceOrganizationWebhooks(cmdFlags.sourceOrg, data.NewAPIGetter(restSourceClient))
+		webhookResponse, err := data.GetSourceOrganizationWebhooks(ctx, cmdFlags.sourceOrg, data.NewAPIGetter(restSourceClient))
 		if err != nil {
 			return err
 		}
-		err = json.Unmarshal(webhookResponse, &webhooksList)
+		var sourceWebhooks []data.Webhook
+		err = json.Unmarshal(webhookResponse, &sourceWebhooks)
 		if err != nil {
 			return err
 		}
+		for _, webhook := range sourceWebhooks {
+			webhooksList = append(webhooksList, data.CreatedWebhook{
+				Name:   webhook.Name,
+				Active: webhook.Active,
+				Events: webhook.Events,
+				Config: webhook.Config,
+			})
+			rows = append(rows, output.CSVRecord(webhook))
+		}
 	} else {
 		zap.S().Errorf("Error arose identifying webhooks")
 	}
+
+	rewriteURLs(webhooksList, rows, profile.RewriteURL)
+
+	guard, err := cmdFlags.guard()
+	if err != nil {
+		return err
+	}
+	if err := guard.Check(webhooksList); err != nil {
+		if errors.Is(err, policy.ErrNoAllowedDomains) {
+			err = fmt.Errorf("%w, set the hosts webhooks may deliver to with --allowed-host or --policy-file", err)
+		}
+		if !cmdFlags.force {
+			return fmt.Errorf("%w\nuse --force to create them anyway", err)
+		}
+		zap.S().Warnf("Creating webhooks despite guardrail violations: %v", err)
+	}
+
+	results := newHookResults(webhooksList, rows)
+	checkpointFile := cmdFlags.checkpointFile
+	if cmdFlags.resume != "" {
+		checkpointFile = cmdFlags.resume
+	} else if checkpointFile == "" {
+		checkpointFile = fmt.Sprintf(".create-%s.json", owner)
+	}
+	cp, err := loadCheckpoint(checkpointFile, owner, cmdFlags.hostname, cmdFlags.resume != "")
+	if err != nil {
+		return err
+	}
+	pending := cp.apply(results)
+	if len(pending) < len(results) {
+		fmt.Printf("Resuming from %s, skipping %d webhooks created by an earlier run.\n", checkpointFile, len(results)-len(pending))
+	}
+
+	if !cmdFlags.skipPreflight {
+		zap.S().Debugf("Running preflight checks for %s", owner)
+		report := preflight.Run(ctx, g, owner, pending, cmdFlags.clientOptions().UsesApp())
+		if err := report.Err(); err != nil {
+			_ = report.Write(os.Stderr)
+			return err
+		}
+	}
 	zap.S().Debugf("Determining webhooks to create")
-	for _, webhook := range webhooksList {
-		if webhook.Config.Secret == "********" {
+	cp.restoreSecrets(results)
+	if err := profileSecrets(results, profile); err != nil {
+		return err
+	}
+	if err := cp.saveSecrets(promptSecrets(results, data.SensitivePrompt)); err != nil {
+		return err
+	}
+
+	// From here on an interrupt stops between webhooks rather than abandoning a write
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	opts := createOptions{FailFast: cmdFlags.failFast, Hostname: cmdFlags.hostname, Checkpoint: cp}
+	if cmdFlags.journalFile != "" {
+		opts.Journal, err = journal.Open(cmdFlags.journalFile)
+		if err != nil {
+			return err
+		}
+		defer closeJournal(opts.Journal)
+	}
+
+	err = createWebhooks(ctx, owner, results, g, opts, os.Stdout)
+	if cmdFlags.reportFile != "" {
+		if reportErr := writeReport(cmdFlags.reportFile, newRunReport(owner, cmdFlags, started, results)); reportErr != nil {
+			if err == nil {
+				return reportErr
+			}
+			zap.S().Errorf("Error writing report to %s: %v", cmdFlags.reportFile, reportErr)
+		}
+	}
+	if err != nil && cmdFlags.failedFile != "" {
+		n, writeErr := writeFailedRows(cmdFlags.failedFile, results)
+		if writeErr != nil {
+			zap.S().Errorf("Error writing webhooks that were not created to %s: %v", cmdFlags.failedFile, writeErr)
+		} else if n > 0 {
+			retry := "--from-file " + cmdFlags.failedFile
+			if cp.saved() {
+				retry += " --resume " + cp.path
+			}
+			fmt.Printf("Wrote %d webhooks that were not created to %s, fix them and retry with %s\n", n, cmdFlags.failedFile, retry)
+		}
+	}
+	cp.finish(err)
+	return err
+}
+
+// closeJournal closes the journal, removing it when nothing was recorded, and
+// otherwise explains how to roll the run back
+func closeJournal(j *journal.Journal) {
+	if err := j.Close(); err != nil {
+		zap.S().Errorf("Error closing journal %s: %v", j.Path, err)
+		return
+	}
+	info, err := os.Stat(j.Path)
+	if err != nil {
+		return
+	}
+	if info.Size() == 0 {
+		_ = os.Remove(j.Path)
+		return
+	}
+	fmt.Printf("Recorded created webhooks in %s, undo them with: gh organization-webhooks rollback %s\n", j.Path, j.Path)
+}
+
+// rewriteURLs rewrites the URL of each webhook and of the input row it came
+// from, so webhooks written to the failed file are retried with the new URL
+func rewriteURLs(webhooks []data.CreatedWebhook, rows [][]string, rewrite func(string) string) {
+	urlColumn := slices.Index(output.CSVHeader, "Config_URL")
+	for i := range webhooks {
+		url := rewrite(webhooks[i].Config.Url)
+		if url == webhooks[i].Config.Url {
+			continue
+		}
+		zap.S().Debugf("Rewrote webhook URL %s to %s", webhooks[i].Config.Url, url)
+		webhooks[i].Config.Url = url
+		if i < len(rows) && len(rows[i]) > urlColumn {
+			rows[i][urlColumn] = url
+		}
+	}
+}
+
+// profileSecrets sets the secrets the profile configures for webhooks still
+// to be created whose secret was redacted
+func profileSecrets(results []hookResult, profile config.Profile) error {
+	for i, result := range results {
+		if result.Resumed || result.Webhook.Config.Secret != "********" {
+			continue
+		}
+		secret, ok, err := profile.Secret(result.Webhook.Config.Url)
+		if err != nil {
+			return err
+		}
+		if ok {
+			results[i].Webhook.Config.Secret = secret
+		}
+	}
+	return nil
+}
+
+// promptSecrets asks for a new secret for every webhook still to be created
+// whose secret was redacted, and returns the secrets entered by checkpoint key
+func promptSecrets(results []hookResult, prompt func(string) string) map[string]string {
+	prompted := map[string]string{}
+	for i, result := range results {
+		if result.Resumed {
+			continue
+		}
+		if webhook := result.Webhook; webhook.Config.Secret == "********" {
 			zap.S().Debugf("Webhook with URL %s required a secret, and needs a new secret to be entered.", webhook.Config.Url)
 			webhookString := fmt.Sprintf("Please enter the new secret to be created with webhook %s:", webhook.Config.Url)
-			webhookSecret := data.SensitivePrompt(webhookString)
-			webhook.Config.Secret = webhookSecret
+			results[i].Webhook.Config.Secret = prompt(webhookString)
+			prompted[checkpointKey(result)] = results[i].Webhook.Config.Secret
+		}
+	}
+	return prompted
+}
+
+// checkpoint records the webhooks a run has created, so that an interrupted
+// run can be resumed without creating them again
+type checkpoint struct {
+	Organization string    `json:"organization"`
+	Hostname     string    `json:"hostname"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	// Completed maps the key of each created input webhook to its new ID
+	Completed map[string]int64 `json:"completed"`
+
+	path string
+	// secrets maps the key of each input webhook to the secret entered for
+	// it. They are kept out of the checkpoint in a file only the user can read.
+	secrets map[string]string
+}
+
+// loadCheckpoint reads the checkpoint at path when resuming. A new run refuses
+// to start over a checkpoint left by an earlier run, as that run's webhooks
+// would be created twice.
+func loadCheckpoint(path, owner, hostname string, resume bool) (*checkpoint, error) {
+	cp := &checkpoint{Organization: owner, Hostname: hostname, Completed: map[string]int64{}, path: path, secrets: map[string]string{}}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if resume {
+			return nil, fmt.Errorf("checkpoint %s does not exist", path)
+		}
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !resume {
+		return nil, fmt.Errorf("checkpoint %s was left by an earlier run, use --resume %s to skip the webhooks it created or delete it to start over", path, path)
+	}
+
+	if err := json.Unmarshal(raw, cp); err != nil {
+		return nil, fmt.Errorf("unable to read checkpoint %s: %w", path, err)
+	}
+	if cp.Organization != owner || cp.Hostname != hostname {
+		return nil, fmt.Errorf("checkpoint %s is for %s on %s, not %s on %s", path, cp.Organization, cp.Hostname, owner, hostname)
+	}
+	if cp.Completed == nil {
+		cp.Completed = map[string]int64{}
+	}
+	raw, err = os.ReadFile(cp.secretsPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(raw, &cp.secrets); err != nil {
+			return nil, fmt.Errorf("unable to read secrets %s: %w", cp.secretsPath(), err)
+		}
+	}
+	return cp, nil
+}
+
+// secretsPath is the file holding the secrets entered during the run
+func (c *checkpoint) secretsPath() string {
+	return c.path + ".secrets"
+}
+
+// restoreSecrets sets the secrets entered during an earlier run for webhooks
+// still to be created whose secret was redacted
+func (c *checkpoint) restoreSecrets(results []hookResult) {
+	for i, result := range results {
+		if result.Resumed || result.Webhook.Config.Secret != "********" {
+			continue
+		}
+		if secret, ok := c.secrets[checkpointKey(result)]; ok {
+			results[i].Webhook.Config.Secret = secret
 		}
+	}
+}
+
+// saveSecrets records the secrets entered during this run, so resuming it
+// doesn't prompt for them again
+func (c *checkpoint) saveSecrets(secrets map[string]string) error {
+	if len(secrets) == 0 {
+		return nil
+	}
+	for key, secret := range secrets {
+		c.secrets[key] = secret
+	}
+	raw, err := json.Marshal(c.secrets)
+	if err != nil {
+		return err
+	}
+	return output.WriteFileAtomic(c.secretsPath(), append(raw, '\n'), 0o600)
+}
+
+// apply marks the webhooks created by an earlier run as resumed and returns
+// the webhooks still to be created
+func (c *checkpoint) apply(results []hookResult) []data.CreatedWebhook {
+	var pending []data.CreatedWebhook
+	for i := range results {
+		if id, ok := c.Completed[checkpointKey(results[i])]; ok {
+			results[i].Resumed = true
+			results[i].TargetID = id
+			continue
+		}
+		pending = append(pending, results[i].Webhook)
+	}
+	return pending
+}
+
+// complete records a created webhook and saves the checkpoint
+func (c *checkpoint) complete(result hookResult) error {
+	c.Completed[checkpointKey(result)] = result.TargetID
+	c.UpdatedAt = time.Now().UTC()
+	return c.save()
+}
+
+// save writes the checkpoint
+func (c *checkpoint) save() error {
+	raw, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return output.WriteFileAtomic(c.path, append(raw, '\n'), 0o644)
+}
+
+// saved reports whether the checkpoint has been written
+func (c *checkpoint) saved() bool {
+	_, err := os.Stat(c.path)
+	return err == nil
+}
+
+// finish removes the checkpoint and its secrets once every webhook has been
+// created, and otherwise keeps them and explains how to resume the run
+func (c *checkpoint) finish(err error) {
+	if err != nil && c.saved() {
+		fmt.Printf("Progress was saved in %s, continue the run with --resume %s\n", c.path, c.path)
+		return
+	}
+	for _, path := range []string{c.path, c.secretsPath()} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			zap.S().Errorf("Error removing checkpoint %s: %v", path, err)
+		}
+	}
+}
+
+// checkpointKey identifies an input webhook independently of its secret,
+// which may have been redacted and prompted for
+func checkpointKey(result hookResult) string {
+	webhook := result.Webhook
+	events := append([]string{}, webhook.Events...)
+	sort.Strings(events)
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		strconv.FormatInt(result.SourceID, 10),
+		webhook.Name,
+		strconv.FormatBool(webhook.Active),
+		strings.Join(events, ";"),
+		webhook.Config.ContentType,
+		webhook.Config.InsecureSSL,
+		webhook.Config.Url,
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Outcomes of creating a webhook
+const (
+	statusCreated = "created"
+	statusFailed  = "failed"
+	statusSkipped = "skipped"
+)
+
+// hookResult tracks a webhook to create, the input row it came from and the
+// outcome of creating it
+type hookResult struct {
+	Webhook  data.CreatedWebhook
+	Row      []string
+	SourceID int64
+	Status   string
+	// Resumed is set when an earlier run already created the webhook
+	Resumed   bool
+	TargetID  int64
+	TargetURL string
+	StartedAt time.Time
+	Duration  time.Duration
+	Err       error
+}
+
+// newHookResults pairs each webhook with its input row. Rows may be nil.
+func newHookResults(webhooks []data.CreatedWebhook, rows [][]string) []hookResult {
+	results := make([]hookResult, len(webhooks))
+	for i, webhook := range webhooks {
+		results[i] = hookResult{Webhook: webhook, Status: statusSkipped}
+		if i < len(rows) {
+			results[i].Row = rows[i]
+			if len(rows[i]) > 1 {
+				results[i].SourceID, _ = strconv.ParseInt(rows[i][1], 10, 64)
+			}
+		}
+	}
+	return results
+}
+
+// createOptions control how a batch of webhooks is created
+type createOptions struct {
+	// FailFast stops the run at the first webhook that fails to be created
+	FailFast bool
+	// Journal, when set, records every webhook created on Hostname
+	Journal  *journal.Journal
+	Hostname string
+	// Checkpoint, when set, records progress so the run can be resumed
+	Checkpoint *checkpoint
+}
+
+// createWebhooks creates the webhooks in order, recording the outcome of each.
+// A failure stops the run with FailFast and is otherwise logged before moving
+// on. Once ctx is done no further webhooks are started, while a write already
+// in flight is allowed to finish.
+func createWebhooks(ctx context.Context, owner string, results []hookResult, g webhookCreator, opts createOptions, out io.Writer) error {
+	var errs []error
+	for i := range results {
+		if ctx.Err() != nil || (opts.FailFast && len(errs) > 0) {
+			break
+		}
+		if results[i].Resumed {
+			continue
+		}
+		webhook := results[i].Webhook
 		createWebhook, err := json.Marshal(webhook)
 
 		if err != nil {
@@ -169,11 +637,265 @@ func runCmdCreate(owner string, cmdFlags *cmdFlags, g *data.APIGetter) error {
 
 		reader := bytes.NewReader(createWebhook)
 		zap.S().Debugf("Creating Webhooks under %s", owner)
-		err = g.CreateOrganizationWebhook(owner, reader)
+		results[i].StartedAt = time.Now()
+		response, err := g.CreateOrganizationWebhook(context.WithoutCancel(ctx), owner, reader)
+		results[i].Duration = time.Since(results[i].StartedAt)
 		if err != nil {
-			zap.S().Errorf("Error arose creating webhook with %s", webhook.Config.Url)
+			zap.S().Errorf("Error arose creating webhook with %s: %v", webhook.Config.Url, err)
+			results[i].Status = statusFailed
+			results[i].Err = err
+			errs = append(errs, err)
+			continue
+		}
+		results[i].Status = statusCreated
+
+		var created struct {
+			ID  int64  `json:"id"`
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal(response, &created); err != nil {
+			zap.S().Debugf("Unable to read the webhook created with %s: %v", webhook.Config.Url, err)
+		}
+		results[i].TargetID = created.ID
+		results[i].TargetURL = created.URL
+
+		if opts.Journal != nil {
+			err := opts.Journal.Record(journal.Entry{
+				Action:        journal.ActionCreate,
+				Hostname:      opts.Hostname,
+				Organization:  owner,
+				HookID:        created.ID,
+				URL:           webhook.Config.Url,
+				PayloadSHA256: journal.PayloadHash(createWebhook),
+			})
+			if err != nil {
+				// Stop rather than make writes that could not be rolled back
+				writeResults(out, owner, results)
+				return fmt.Errorf("recording webhook %d in journal %s: %w", created.ID, opts.Journal.Path, err)
+			}
+		}
+		if opts.Checkpoint != nil {
+			if err := opts.Checkpoint.complete(results[i]); err != nil {
+				writeResults(out, owner, results)
+				return fmt.Errorf("saving checkpoint %s: %w", opts.Checkpoint.path, err)
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		reason := "interrupted"
+		if errors.Is(err, context.DeadlineExceeded) {
+			reason = "timed out"
 		}
+		fmt.Fprintf(out, "Stopped creating webhooks for %s (%s).\n", owner, reason)
+		writeResults(out, owner, results)
+		return fmt.Errorf("creating webhooks for %s %s: %w", owner, reason, err)
 	}
-	fmt.Printf("Successfully created webhooks for: %s.", owner)
+	if len(errs) > 0 {
+		writeResults(out, owner, results)
+		return &createFailures{owner: owner, total: len(results), errs: errs}
+	}
+	fmt.Fprintf(out, "Successfully created webhooks for: %s.", owner)
 	return nil
 }
+
+// writeResults lists the webhooks that were and weren't created
+func writeResults(out io.Writer, owner string, results []hookResult) {
+	var created, resumed, notCreated []hookResult
+	for _, result := range results {
+		switch {
+		case result.Status == statusCreated:
+			created = append(created, result)
+		case result.Resumed:
+			resumed = append(resumed, result)
+		default:
+			notCreated = append(notCreated, result)
+		}
+	}
+	fmt.Fprintf(out, "Created %d of %d webhooks for %s:\n", len(created), len(results), owner)
+	for _, result := range created {
+		fmt.Fprintf(out, "  %s\n", result.Webhook.Config.Url)
+	}
+	if len(resumed) > 0 {
+		fmt.Fprintf(out, "Created by an earlier run:\n")
+		for _, result := range resumed {
+			fmt.Fprintf(out, "  %s\n", result.Webhook.Config.Url)
+		}
+	}
+	fmt.Fprintf(out, "Not created:\n")
+	for _, result := range notCreated {
+		fmt.Fprintf(out, "  %s (%s)\n", result.Webhook.Config.Url, result.reason())
+	}
+}
+
+// reason explains why the webhook was not created
+func (r hookResult) reason() string {
+	if r.Err != nil {
+		return strings.Join(strings.Fields(strings.ReplaceAll(r.Err.Error(), "\n", "; ")), " ")
+	}
+	if r.Resumed {
+		return "created by an earlier run"
+	}
+	return "not attempted"
+}
+
+// writeFailedRows writes every webhook that wasn't created to a CSV file in the
+// `--from-file` format, with the reason in an extra Error column, so they can
+// be fixed and retried. It returns the number of rows written.
+func writeFailedRows(fileName string, results []hookResult) (int, error) {
+	var rows [][]string
+	for _, result := range results {
+		if result.Status == statusCreated || result.Resumed {
+			continue
+		}
+		row := make([]string, len(output.CSVHeader), len(output.CSVHeader)+1)
+		if result.Row != nil {
+			copy(row, result.Row)
+		} else {
+			row = output.CSVRecord(data.Webhook{
+				HookType: "Organization",
+				Name:     result.Webhook.Name,
+				Active:   result.Webhook.Active,
+				Events:   result.Webhook.Events,
+				Config:   result.Webhook.Config,
+			})
+		}
+		rows = append(rows, append(row, result.reason()))
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			zap.S().Errorf("Error closing file: %v", err)
+		}
+	}()
+
+	csvWriter := csv.NewWriter(f)
+	if err := csvWriter.Write(append(append([]string{}, output.CSVHeader...), "Error")); err != nil {
+		return 0, err
+	}
+	if err := csvWriter.WriteAll(rows); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// runReport is the JSON report written to --report-file
+type runReport struct {
+	Organization       string       `json:"organization"`
+	SourceOrganization string       `json:"source_organization,omitempty"`
+	FromFile           string       `json:"from_file,omitempty"`
+	StartedAt          time.Time    `json:"started_at"`
+	FinishedAt         time.Time    `json:"finished_at"`
+	DurationMS         int64        `json:"duration_ms"`
+	Created            int          `json:"created"`
+	Failed             int          `json:"failed"`
+	Skipped            int          `json:"skipped"`
+	Hooks              []reportHook `json:"hooks"`
+}
+
+type reportHook struct {
+	SourceHookID  int64        `json:"source_hook_id,omitempty"`
+	Name          string       `json:"name"`
+	URL           string       `json:"url"`
+	Events        []string     `json:"events"`
+	Action        string       `json:"action"`
+	Reason        string       `json:"reason,omitempty"`
+	TargetHookID  int64        `json:"target_hook_id,omitempty"`
+	TargetHookURL string       `json:"target_hook_url,omitempty"`
+	StartedAt     *time.Time   `json:"started_at,omitempty"`
+	DurationMS    int64        `json:"duration_ms"`
+	Error         *reportError `json:"error,omitempty"`
+}
+
+type reportError struct {
+	Message    string            `json:"message"`
+	Type       string            `json:"type,omitempty"`
+	StatusCode int               `json:"status_code,omitempty"`
+	Errors     []data.FieldError `json:"errors,omitempty"`
+}
+
+// newRunReport builds the report of a run that started at started
+func newRunReport(owner string, cmdFlags *cmdFlags, started time.Time, results []hookResult) runReport {
+	finished := time.Now()
+	report := runReport{
+		Organization:       owner,
+		SourceOrganization: cmdFlags.sourceOrg,
+		FromFile:           cmdFlags.fileName,
+		StartedAt:          started.UTC(),
+		FinishedAt:         finished.UTC(),
+		DurationMS:         finished.Sub(started).Milliseconds(),
+		Hooks:              []reportHook{},
+	}
+	for _, result := range results {
+		hook := reportHook{
+			SourceHookID:  result.SourceID,
+			Name:          result.Webhook.Name,
+			URL:           result.Webhook.Config.Url,
+			Events:        result.Webhook.Events,
+			Action:        result.Status,
+			TargetHookID:  result.TargetID,
+			TargetHookURL: result.TargetURL,
+			DurationMS:    result.Duration.Milliseconds(),
+		}
+		if result.Status == statusSkipped {
+			hook.Reason = result.reason()
+		}
+		if !result.StartedAt.IsZero() {
+			startedAt := result.StartedAt.UTC()
+			hook.StartedAt = &startedAt
+		}
+		if result.Err != nil {
+			hook.Error = &reportError{Message: result.Err.Error()}
+			var apiErr *data.APIError
+			if errors.As(result.Err, &apiErr) {
+				hook.Error.StatusCode = apiErr.StatusCode
+				hook.Error.Errors = apiErr.Errors
+				if apiErr.Kind != nil {
+					hook.Error.Type = strings.ReplaceAll(apiErr.Kind.Error(), " ", "_")
+				}
+			}
+		}
+		switch result.Status {
+		case statusCreated:
+			report.Created++
+		case statusFailed:
+			report.Failed++
+		default:
+			report.Skipped++
+		}
+		report.Hooks = append(report.Hooks, hook)
+	}
+	return report
+}
+
+// writeReport writes the run report as indented JSON
+func writeReport(fileName string, report runReport) error {
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, append(b, '\n'), 0644)
+}
+
+// createFailures is returned when some webhooks could not be created. The
+// individual errors have already been reported, and are kept for the exit code.
+type createFailures struct {
+	owner string
+	total int
+	errs  []error
+}
+
+func (e *createFailures) Error() string {
+	return fmt.Sprintf("failed to create %d of %d webhooks for %s", len(e.errs), e.total, e.owner)
+}
+
+func (e *createFailures) Unwrap() []error {
+	return e.errs
+}
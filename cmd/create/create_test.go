@@ -2,13 +2,22 @@ package create
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/katiem0/gh-organization-webhooks/internal/config"
 	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/journal"
+	"github.com/katiem0/gh-organization-webhooks/internal/output"
 )
 
 func TestNewCmdCreate(t *testing.T) {
@@ -19,8 +28,8 @@ func TestNewCmdCreate(t *testing.T) {
 	}
 
 	// Test basic properties
-	if cmd.Use != "create <target organization> [flags]" {
-		t.Errorf("Expected Use to be 'create <target organization> [flags]', got %s", cmd.Use)
+	if cmd.Use != "create [<target organization>] [flags]" {
+		t.Errorf("Expected Use to be 'create [<target organization>] [flags]', got %s", cmd.Use)
 	}
 
 	// Test flags
@@ -40,6 +49,12 @@ func TestNewCmdCreate(t *testing.T) {
 		t.Error("source-token flag not found")
 	}
 
+	for _, name := range []string{"app-id", "app-private-key", "installation-id", "source-app-id", "source-app-private-key", "source-installation-id", "allowed-host", "policy-file", "force", "write-rate", "write-spacing", "write-concurrency", "timeout", "request-timeout", "fail-fast", "failed-file", "report-file", "journal", "checkpoint", "resume"} {
+		if cmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+
 	// Test short description
 	if cmd.Short == "" {
 		t.Error("Command should have a short description")
@@ -100,7 +115,7 @@ func runCmdCreateTest(owner string, cmdFlags *cmdFlags, g interface{}) error {
 	for _, webhook := range webhooksList {
 		webhookData, _ := json.Marshal(webhook)
 		reader := bytes.NewReader(webhookData)
-		err := getter.CreateOrganizationWebhook(owner, reader)
+		_, err := getter.CreateOrganizationWebhook(context.Background(), owner, reader)
 		if err != nil {
 			return err
 		}
@@ -265,3 +280,569 @@ func TestRunCmdCreateErrorCases(t *testing.T) {
 		}
 	})
 }
+
+func TestCmdFlagsGuard(t *testing.T) {
+	policyFile := filepath.Join(t.TempDir(), "policy.yml")
+	if err := os.WriteFile(policyFile, []byte("allowed_domains: [\"*.corp.example.com\"]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write policy file: %v", err)
+	}
+
+	flags := &cmdFlags{
+		allowedHosts: []string{"hooks.example.com"},
+		policyFile:   policyFile,
+	}
+	guard, err := flags.guard()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	allowed := []data.CreatedWebhook{
+		{Config: data.Config{Url: "https://hooks.example.com/github", InsecureSSL: "0"}},
+		{Config: data.Config{Url: "https://ci.corp.example.com/github", InsecureSSL: "0"}},
+	}
+	if err := guard.Check(allowed); err != nil {
+		t.Errorf("Expected webhooks to be allowed, got %v", err)
+	}
+
+	rejected := []data.CreatedWebhook{{Config: data.Config{Url: "https://exfil.example.net/collect", InsecureSSL: "0"}}}
+	if err := guard.Check(rejected); err == nil {
+		t.Error("Expected webhook outside the allowlist to be rejected, got nil")
+	}
+
+	flags.policyFile = filepath.Join(t.TempDir(), "missing.yml")
+	if _, err := flags.guard(); err == nil {
+		t.Error("Expected error for missing policy file, got nil")
+	}
+}
+
+// cancelingCreator cancels the run after a number of webhooks have been created
+type cancelingCreator struct {
+	cancel  context.CancelFunc
+	after   int
+	created []string
+}
+
+func (c *cancelingCreator) CreateOrganizationWebhook(ctx context.Context, owner string, body io.Reader) ([]byte, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	var webhook data.CreatedWebhook
+	if err := json.NewDecoder(body).Decode(&webhook); err != nil {
+		return nil, err
+	}
+	c.created = append(c.created, webhook.Config.Url)
+	if len(c.created) == c.after {
+		c.cancel()
+	}
+	return nil, nil
+}
+
+func TestCreateWebhooksStopsWhenCanceled(t *testing.T) {
+	webhooks := []data.CreatedWebhook{
+		{Name: "web", Config: data.Config{Url: "https://one.example.com/hook"}},
+		{Name: "web", Config: data.Config{Url: "https://two.example.com/hook"}},
+		{Name: "web", Config: data.Config{Url: "https://three.example.com/hook"}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	creator := &cancelingCreator{cancel: cancel, after: 2}
+
+	var out bytes.Buffer
+	err := createWebhooks(ctx, "test-org", newHookResults(webhooks, nil), creator, createOptions{}, &out)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if len(creator.created) != 2 {
+		t.Errorf("Expected the in flight webhook to finish and no more to start, created %v", creator.created)
+	}
+	report := out.String()
+	if !strings.Contains(report, "Created 2 of 3 webhooks") {
+		t.Errorf("Expected created count in output, got %q", report)
+	}
+	if !strings.Contains(report, "Not created:\n  https://three.example.com/hook") {
+		t.Errorf("Expected remaining webhook in output, got %q", report)
+	}
+}
+
+func TestCreateWebhooksTimedOut(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	var out bytes.Buffer
+	webhooks := []data.CreatedWebhook{{Name: "web", Config: data.Config{Url: "https://one.example.com/hook"}}}
+	err := createWebhooks(ctx, "test-org", newHookResults(webhooks, nil), data.NewMockAPIGetter(), createOptions{}, &out)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if !strings.Contains(out.String(), "(timed out)") {
+		t.Errorf("Expected timeout reason in output, got %q", out.String())
+	}
+}
+
+func TestCreateWebhooks(t *testing.T) {
+	var out bytes.Buffer
+	webhooks := []data.CreatedWebhook{{Name: "web", Config: data.Config{Url: "https://one.example.com/hook"}}}
+	if err := createWebhooks(context.Background(), "test-org", newHookResults(webhooks, nil), data.NewMockAPIGetter(), createOptions{}, &out); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if out.String() != "Successfully created webhooks for: test-org." {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
+
+func TestCreateWebhooksFailures(t *testing.T) {
+	mockGetter := data.NewMockAPIGetter()
+	validationErr := &data.APIError{Kind: data.ErrValidationFailed, Method: "POST", Path: "/orgs/test-org/hooks", StatusCode: 422}
+	mockGetter.MethodErrors = map[string]error{"CreateOrganizationWebhook": validationErr}
+
+	var out bytes.Buffer
+	webhooks := []data.CreatedWebhook{
+		{Name: "web", Config: data.Config{Url: "https://one.example.com/hook"}},
+		{Name: "web", Config: data.Config{Url: "https://two.example.com/hook"}},
+	}
+	err := createWebhooks(context.Background(), "test-org", newHookResults(webhooks, nil), mockGetter, createOptions{}, &out)
+	if err == nil || err.Error() != "failed to create 2 of 2 webhooks for test-org" {
+		t.Fatalf("Expected failure summary, got %v", err)
+	}
+	if !errors.Is(err, data.ErrValidationFailed) {
+		t.Errorf("Expected failures to wrap the API error, got %v", err)
+	}
+	if strings.Contains(out.String(), "Successfully") {
+		t.Errorf("Expected no success message, got %q", out.String())
+	}
+}
+
+// failingCreator fails to create webhooks with the given URLs
+type failingCreator struct {
+	fail    map[string]error
+	created []string
+}
+
+func (c *failingCreator) CreateOrganizationWebhook(ctx context.Context, owner string, body io.Reader) ([]byte, error) {
+	var webhook data.CreatedWebhook
+	if err := json.NewDecoder(body).Decode(&webhook); err != nil {
+		return nil, err
+	}
+	if err := c.fail[webhook.Config.Url]; err != nil {
+		return nil, err
+	}
+	c.created = append(c.created, webhook.Config.Url)
+	id := 1000 + len(c.created)
+	return []byte(fmt.Sprintf(`{"id":%d,"url":"https://api.github.com/orgs/%s/hooks/%d"}`, id, owner, id)), nil
+}
+
+func TestCreateWebhooksContinuesOnError(t *testing.T) {
+	webhooks := []data.CreatedWebhook{
+		{Name: "web", Config: data.Config{Url: "https://one.example.com/hook"}},
+		{Name: "web", Config: data.Config{Url: "https://two.example.com/hook"}},
+		{Name: "web", Config: data.Config{Url: "https://three.example.com/hook"}},
+	}
+	creator := &failingCreator{fail: map[string]error{"https://two.example.com/hook": errors.New("Hook already exists on this organization")}}
+
+	var out bytes.Buffer
+	results := newHookResults(webhooks, nil)
+	err := createWebhooks(context.Background(), "test-org", results, creator, createOptions{}, &out)
+	if err == nil || err.Error() != "failed to create 1 of 3 webhooks for test-org" {
+		t.Fatalf("Expected failure summary, got %v", err)
+	}
+	if len(creator.created) != 2 {
+		t.Errorf("Expected the remaining webhooks to be created, created %v", creator.created)
+	}
+	for i, want := range []string{statusCreated, statusFailed, statusCreated} {
+		if results[i].Status != want {
+			t.Errorf("Expected result %d to be %s, got %s", i, want, results[i].Status)
+		}
+	}
+	if !strings.Contains(out.String(), "https://two.example.com/hook (Hook already exists on this organization)") {
+		t.Errorf("Expected failure reason in output, got %q", out.String())
+	}
+}
+
+func TestCreateWebhooksFailFast(t *testing.T) {
+	webhooks := []data.CreatedWebhook{
+		{Name: "web", Config: data.Config{Url: "https://one.example.com/hook"}},
+		{Name: "web", Config: data.Config{Url: "https://two.example.com/hook"}},
+	}
+	creator := &failingCreator{fail: map[string]error{"https://one.example.com/hook": errors.New("boom")}}
+
+	var out bytes.Buffer
+	results := newHookResults(webhooks, nil)
+	if err := createWebhooks(context.Background(), "test-org", results, creator, createOptions{FailFast: true}, &out); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if len(creator.created) != 0 {
+		t.Errorf("Expected no webhooks after the failure to be created, created %v", creator.created)
+	}
+	if results[1].Status != statusSkipped {
+		t.Errorf("Expected second webhook to be skipped, got %s", results[1].Status)
+	}
+	if !strings.Contains(out.String(), "https://two.example.com/hook (not attempted)") {
+		t.Errorf("Expected skipped webhook in output, got %q", out.String())
+	}
+}
+
+func TestWriteFailedRows(t *testing.T) {
+	rows := [][]string{
+		{"Organization", "1", "web", "true", "push", "json", "0", "s3cret", "https://one.example.com/hook", "2023-01-01", "2023-01-01"},
+		{"Organization", "2", "web", "true", "push;issues", "json", "0", "s3cret", "https://two.example.com/hook", "2023-01-01", "2023-01-01", "previous error"},
+	}
+	results := []hookResult{
+		{Row: rows[0], Status: statusCreated},
+		{Row: rows[1], Status: statusFailed, Err: errors.New("validation failed\n  Hook.config.url is invalid")},
+		{Webhook: data.CreatedWebhook{Name: "web", Active: true, Events: []string{"push"}, Config: data.Config{Url: "https://three.example.com/hook", Secret: "********"}}, Status: statusSkipped},
+	}
+
+	fileName := filepath.Join(t.TempDir(), "failed.csv")
+	n, err := writeFailedRows(fileName, results)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 rows written, got %d", n)
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		t.Fatalf("Failed to open failed rows: %v", err)
+	}
+	defer f.Close()
+	written, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read failed rows: %v", err)
+	}
+	if len(written) != 3 || written[0][len(written[0])-1] != "Error" {
+		t.Fatalf("Unexpected failed rows %v", written)
+	}
+	if written[1][8] != "https://two.example.com/hook" || written[1][11] != "validation failed; Hook.config.url is invalid" {
+		t.Errorf("Unexpected failed row %v", written[1])
+	}
+	if written[2][7] != "********" || written[2][8] != "https://three.example.com/hook" || written[2][11] != "not attempted" {
+		t.Errorf("Unexpected skipped row %v", written[2])
+	}
+
+	// The failed rows can be read back as input
+	webhooks := data.NewAPIGetter(nil).CreateWebhookList(written)
+	if len(webhooks) != 2 || webhooks[0].Events[1] != "issues" {
+		t.Errorf("Expected failed rows to be valid input, got %+v", webhooks)
+	}
+}
+
+func TestWriteFailedRowsAllCreated(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "failed.csv")
+	n, err := writeFailedRows(fileName, []hookResult{{Status: statusCreated}})
+	if err != nil || n != 0 {
+		t.Fatalf("Expected nothing written, got %d (%v)", n, err)
+	}
+	if _, err := os.Stat(fileName); !os.IsNotExist(err) {
+		t.Errorf("Expected no file to be written, got %v", err)
+	}
+}
+
+func TestRunReport(t *testing.T) {
+	rows := [][]string{
+		{"Organization", "101", "web", "true", "push", "json", "0", "s3cret", "https://one.example.com/hook", "2023-01-01", "2023-01-01"},
+		{"Organization", "102", "web", "true", "push", "json", "0", "s3cret", "https://two.example.com/hook", "2023-01-01", "2023-01-01"},
+		{"Organization", "103", "web", "true", "push", "json", "0", "s3cret", "https://three.example.com/hook", "2023-01-01", "2023-01-01"},
+	}
+	webhooks := data.NewAPIGetter(nil).CreateWebhookList(append([][]string{output.CSVHeader}, rows...))
+	validationErr := &data.APIError{
+		Kind:       data.ErrValidationFailed,
+		StatusCode: 422,
+		Message:    "Validation Failed",
+		Errors:     []data.FieldError{{Resource: "Hook", Field: "config.url", Code: "invalid"}},
+	}
+	creator := &failingCreator{fail: map[string]error{"https://two.example.com/hook": validationErr}}
+
+	started := time.Now()
+	results := newHookResults(webhooks, rows)
+	var out bytes.Buffer
+	_ = createWebhooks(context.Background(), "test-org", results, creator, createOptions{FailFast: true}, &out)
+
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	flags := &cmdFlags{sourceOrg: "source-org"}
+	if err := writeReport(reportFile, newRunReport("test-org", flags, started, results)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	b, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+	var report runReport
+	if err := json.Unmarshal(b, &report); err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+
+	if report.Organization != "test-org" || report.SourceOrganization != "source-org" {
+		t.Errorf("Unexpected organizations in report %+v", report)
+	}
+	if report.Created != 1 || report.Failed != 1 || report.Skipped != 1 || len(report.Hooks) != 3 {
+		t.Fatalf("Unexpected counts in report %+v", report)
+	}
+
+	created := report.Hooks[0]
+	if created.Action != statusCreated || created.SourceHookID != 101 || created.TargetHookID != 1001 ||
+		created.TargetHookURL != "https://api.github.com/orgs/test-org/hooks/1001" || created.StartedAt == nil {
+		t.Errorf("Unexpected created hook %+v", created)
+	}
+
+	failed := report.Hooks[1]
+	if failed.Action != statusFailed || failed.SourceHookID != 102 || failed.TargetHookID != 0 || failed.Error == nil {
+		t.Fatalf("Unexpected failed hook %+v", failed)
+	}
+	if failed.Error.Type != "validation_failed" || failed.Error.StatusCode != 422 || len(failed.Error.Errors) != 1 || failed.Error.Errors[0].Field != "config.url" {
+		t.Errorf("Unexpected error details %+v", failed.Error)
+	}
+
+	skipped := report.Hooks[2]
+	if skipped.Action != statusSkipped || skipped.StartedAt != nil || skipped.Error != nil || skipped.URL != "https://three.example.com/hook" {
+		t.Errorf("Unexpected skipped hook %+v", skipped)
+	}
+	if strings.Contains(string(b), "s3cret") {
+		t.Error("Expected report not to contain webhook secrets")
+	}
+}
+
+func TestCreateWebhooksJournal(t *testing.T) {
+	webhooks := []data.CreatedWebhook{
+		{Name: "web", Config: data.Config{Url: "https://one.example.com/hook"}},
+		{Name: "web", Config: data.Config{Url: "https://two.example.com/hook"}},
+	}
+	creator := &failingCreator{fail: map[string]error{"https://two.example.com/hook": errors.New("boom")}}
+
+	journalFile := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, err := journal.Open(journalFile)
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+	var out bytes.Buffer
+	opts := createOptions{Journal: j, Hostname: "github.com"}
+	_ = createWebhooks(context.Background(), "test-org", newHookResults(webhooks, nil), creator, opts, &out)
+	_ = j.Close()
+
+	entries, err := journal.Read(journalFile)
+	if err != nil {
+		t.Fatalf("Failed to read journal: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected only the created webhook to be recorded, got %+v", entries)
+	}
+	payload, _ := json.Marshal(webhooks[0])
+	e := entries[0]
+	if e.Action != journal.ActionCreate || e.Hostname != "github.com" || e.Organization != "test-org" ||
+		e.HookID != 1001 || e.URL != "https://one.example.com/hook" || e.PayloadSHA256 != journal.PayloadHash(payload) {
+		t.Errorf("Unexpected journal entry %+v", e)
+	}
+}
+
+func TestCheckpointResume(t *testing.T) {
+	webhooks := []data.CreatedWebhook{
+		{Name: "web", Events: []string{"push"}, Config: data.Config{Url: "https://one.example.com/hook", Secret: "********"}},
+		{Name: "web", Events: []string{"push"}, Config: data.Config{Url: "https://two.example.com/hook", Secret: "********"}},
+		{Name: "web", Events: []string{"push"}, Config: data.Config{Url: "https://three.example.com/hook", Secret: "********"}},
+	}
+	path := filepath.Join(t.TempDir(), ".create-test-org.json")
+
+	// The first run creates a webhook and fails on the second
+	cp, err := loadCheckpoint(path, "test-org", "github.com", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	first := newHookResults(webhooks, nil)
+	first[0].Webhook.Config.Secret = "first-secret"
+	creator := &failingCreator{fail: map[string]error{"https://two.example.com/hook": errors.New("connection reset")}}
+	var out bytes.Buffer
+	err = createWebhooks(context.Background(), "test-org", first, creator, createOptions{FailFast: true, Checkpoint: cp}, &out)
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	cp.finish(err)
+
+	// A fresh run refuses to start over the checkpoint
+	if _, err := loadCheckpoint(path, "test-org", "github.com", false); err == nil || !strings.Contains(err.Error(), "--resume") {
+		t.Errorf("Expected error suggesting --resume, got %v", err)
+	}
+	if _, err := loadCheckpoint(path, "other-org", "github.com", true); err == nil {
+		t.Error("Expected error resuming a checkpoint for another organization, got nil")
+	}
+
+	// Resuming skips the created webhook, whose secret isn't needed again
+	cp, err = loadCheckpoint(path, "test-org", "github.com", true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second := newHookResults(webhooks, nil)
+	pending := cp.apply(second)
+	if len(pending) != 2 || !second[0].Resumed || second[0].TargetID != 1001 {
+		t.Fatalf("Expected the first webhook to be resumed, got %+v", second)
+	}
+
+	creator = &failingCreator{}
+	out.Reset()
+	err = createWebhooks(context.Background(), "test-org", second, creator, createOptions{Checkpoint: cp}, &out)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(creator.created) != 2 || creator.created[0] != "https://two.example.com/hook" {
+		t.Errorf("Expected only the remaining webhooks to be created, created %v", creator.created)
+	}
+	cp.finish(err)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected checkpoint to be removed after a complete run, got %v", err)
+	}
+}
+
+func TestCheckpointKeptWithFailedFile(t *testing.T) {
+	webhooks := []data.CreatedWebhook{
+		{Name: "web", Events: []string{"push"}, Config: data.Config{Url: "https://one.example.com/hook"}},
+		{Name: "web", Events: []string{"push"}, Config: data.Config{Url: "https://two.example.com/hook"}},
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".create-test-org.json")
+
+	cp, err := loadCheckpoint(path, "test-org", "github.com", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	results := newHookResults(webhooks, nil)
+	creator := &failingCreator{fail: map[string]error{"https://two.example.com/hook": errors.New("connection reset")}}
+	err = createWebhooks(context.Background(), "test-org", results, creator, createOptions{Checkpoint: cp}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	failedFile := filepath.Join(dir, "failed.csv")
+	if n, err := writeFailedRows(failedFile, results); err != nil || n != 1 {
+		t.Fatalf("writeFailedRows() = %d, %v", n, err)
+	}
+	cp.finish(err)
+	if !cp.saved() {
+		t.Fatal("Expected the checkpoint to be kept after a failed run")
+	}
+
+	// Retrying the failed webhook resumes from the checkpoint
+	cp, err = loadCheckpoint(path, "test-org", "github.com", true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if pending := cp.apply(newHookResults(webhooks[1:], nil)); len(pending) != 1 {
+		t.Errorf("Expected the failed webhook to still be pending, got %v", pending)
+	}
+}
+
+func TestCheckpointResumeReusesSecrets(t *testing.T) {
+	webhooks := []data.CreatedWebhook{
+		{Name: "web", Events: []string{"push"}, Config: data.Config{Url: "https://one.example.com/hook", Secret: "********"}},
+		{Name: "web", Events: []string{"push"}, Config: data.Config{Url: "https://two.example.com/hook", Secret: "********"}},
+	}
+	path := filepath.Join(t.TempDir(), ".create-test-org.json")
+
+	cp, err := loadCheckpoint(path, "test-org", "github.com", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	first := newHookResults(webhooks, nil)
+	cp.restoreSecrets(first)
+	prompted := promptSecrets(first, func(string) string { return "entered-secret" })
+	if err := cp.saveSecrets(prompted); err != nil {
+		t.Fatalf("saveSecrets() error = %v", err)
+	}
+	info, err := os.Stat(cp.secretsPath())
+	if err != nil {
+		t.Fatalf("Expected secrets to be saved, got %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected secrets to only be readable by the user, got %v", info.Mode().Perm())
+	}
+	creator := &failingCreator{fail: map[string]error{"https://two.example.com/hook": errors.New("connection reset")}}
+	err = createWebhooks(context.Background(), "test-org", first, creator, createOptions{Checkpoint: cp}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	cp.finish(err)
+
+	// The resumed run reuses the secret entered for the remaining webhook
+	cp, err = loadCheckpoint(path, "test-org", "github.com", true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second := newHookResults(webhooks, nil)
+	cp.apply(second)
+	cp.restoreSecrets(second)
+	promptSecrets(second, func(message string) string {
+		t.Errorf("Expected no prompt when resuming, got %q", message)
+		return ""
+	})
+	if second[1].Webhook.Config.Secret != "entered-secret" {
+		t.Errorf("Expected the secret entered earlier, got %q", second[1].Webhook.Config.Secret)
+	}
+
+	cp.finish(nil)
+	if _, err := os.Stat(cp.secretsPath()); !os.IsNotExist(err) {
+		t.Errorf("Expected secrets to be removed after a complete run, got %v", err)
+	}
+}
+
+func TestLoadCheckpointMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.json")
+	if _, err := loadCheckpoint(path, "test-org", "github.com", true); err == nil {
+		t.Error("Expected error resuming from a missing checkpoint, got nil")
+	}
+}
+
+func TestCheckpointKeyIgnoresSecret(t *testing.T) {
+	webhook := data.CreatedWebhook{Name: "web", Events: []string{"push", "issues"}, Config: data.Config{Url: "https://one.example.com/hook", Secret: "********"}}
+	prompted := webhook
+	prompted.Events = []string{"issues", "push"}
+	prompted.Config.Secret = "s3cret"
+	if checkpointKey(hookResult{Webhook: webhook}) != checkpointKey(hookResult{Webhook: prompted}) {
+		t.Error("Expected key to ignore the secret and event order")
+	}
+
+	other := webhook
+	other.Config.Url = "https://two.example.com/hook"
+	if checkpointKey(hookResult{Webhook: webhook}) == checkpointKey(hookResult{Webhook: other}) {
+		t.Error("Expected webhooks with different URLs to have different keys")
+	}
+}
+
+func TestRewriteURLs(t *testing.T) {
+	webhooks := []data.CreatedWebhook{
+		{Config: data.Config{Url: "https://jenkins.old.example.com/github-webhook/"}},
+		{Config: data.Config{Url: "https://ci.example.com/github"}},
+	}
+	rows := [][]string{
+		output.CSVRecord(data.Webhook{Config: webhooks[0].Config}),
+		output.CSVRecord(data.Webhook{Config: webhooks[1].Config}),
+	}
+	profile := config.Profile{Rewrite: []config.Rewrite{{From: "https://jenkins.old.example.com/", To: "https://jenkins.example.com/"}}}
+
+	rewriteURLs(webhooks, rows, profile.RewriteURL)
+
+	if webhooks[0].Config.Url != "https://jenkins.example.com/github-webhook/" || rows[0][8] != webhooks[0].Config.Url {
+		t.Errorf("Expected webhook and row to be rewritten, got %s and %v", webhooks[0].Config.Url, rows[0])
+	}
+	if webhooks[1].Config.Url != "https://ci.example.com/github" {
+		t.Errorf("Expected unmatched URL to be unchanged, got %s", webhooks[1].Config.Url)
+	}
+}
+
+func TestProfileSecrets(t *testing.T) {
+	results := []hookResult{
+		{Webhook: data.CreatedWebhook{Config: data.Config{Url: "https://ci.example.com/github", Secret: "********"}}},
+		{Webhook: data.CreatedWebhook{Config: data.Config{Url: "https://chat.example.com/github", Secret: "********"}}},
+		{Webhook: data.CreatedWebhook{Config: data.Config{Url: "https://ci.example.com/github", Secret: "********"}}, Resumed: true},
+	}
+	profile := config.Profile{Secrets: map[string]string{"https://ci.example.com/github": "ci-secret"}}
+
+	if err := profileSecrets(results, profile); err != nil {
+		t.Fatalf("profileSecrets() error = %v", err)
+	}
+	if results[0].Webhook.Config.Secret != "ci-secret" {
+		t.Errorf("Expected secret from the profile, got %q", results[0].Webhook.Config.Secret)
+	}
+	if results[1].Webhook.Config.Secret != "********" || results[2].Webhook.Config.Secret != "********" {
+		t.Errorf("Expected other webhooks to still need a secret, got %+v", results)
+	}
+}
@@ -0,0 +1,93 @@
+package doctor
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-organization-webhooks/internal/client"
+	"github.com/katiem0/gh-organization-webhooks/internal/config"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/log"
+	"github.com/katiem0/gh-organization-webhooks/internal/preflight"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type doctorCmdFlags struct {
+	token          string
+	hostname       string
+	appID          int64
+	appPrivateKey  string
+	installationID int64
+	timeout        time.Duration
+	requestTimeout time.Duration
+	debug          bool
+}
+
+func NewCmdDoctor() *cobra.Command {
+	doctorCmdFlags := doctorCmdFlags{}
+
+	doctorCmd := &cobra.Command{
+		Use:   "doctor [<organization>] [flags]",
+		Short: "Check permissions for managing organization webhooks",
+		Long:  "Check token scopes, organization membership role, that the organization exists and the current webhook count against GitHub's limits",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(doctorCmd *cobra.Command, args []string) error {
+			var err error
+			var restClient *api.RESTClient
+
+			// Reinitialize logging if debugging was enabled
+			if doctorCmdFlags.debug {
+				logger, _ := log.NewLogger(doctorCmdFlags.debug)
+				defer logger.Sync() // nolint:errcheck
+				zap.ReplaceGlobals(logger)
+			}
+
+			opts := client.Options{
+				Hostname:       doctorCmdFlags.hostname,
+				Token:          doctorCmdFlags.token,
+				AppID:          doctorCmdFlags.appID,
+				AppPrivateKey:  doctorCmdFlags.appPrivateKey,
+				InstallationID: doctorCmdFlags.installationID,
+				RequestTimeout: doctorCmdFlags.requestTimeout,
+			}
+			restClient, err = client.NewRESTClient(opts)
+			if err != nil {
+				zap.S().Errorf("Error arose retrieving rest client: %v", err)
+				return err
+			}
+
+			ctx, cancel := client.CommandContext(doctorCmdFlags.timeout)
+			defer cancel()
+
+			owner, err := config.FromContext(doctorCmd.Context()).OrganizationArg(args, 0)
+			if err != nil {
+				return err
+			}
+
+			return runCmdDoctor(ctx, owner, data.NewAPIGetter(restClient), opts.UsesApp())
+		},
+	}
+
+	// Configure flags for command
+	doctorCmd.PersistentFlags().StringVarP(&doctorCmdFlags.token, "token", "t", "", `GitHub personal access token for the organization (default "gh auth token")`)
+	doctorCmd.PersistentFlags().StringVarP(&doctorCmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
+	doctorCmd.PersistentFlags().Int64VarP(&doctorCmdFlags.appID, "app-id", "", 0, "GitHub App ID used to authenticate instead of a token (Requires --app-private-key and --installation-id)")
+	doctorCmd.PersistentFlags().StringVarP(&doctorCmdFlags.appPrivateKey, "app-private-key", "", "", "Path to the GitHub App private key PEM file")
+	doctorCmd.PersistentFlags().Int64VarP(&doctorCmdFlags.installationID, "installation-id", "", 0, "GitHub App installation ID for the organization")
+	client.AddTimeoutFlags(doctorCmd.PersistentFlags(), &doctorCmdFlags.timeout, &doctorCmdFlags.requestTimeout)
+	doctorCmd.PersistentFlags().BoolVarP(&doctorCmdFlags.debug, "debug", "d", false, "To debug logging")
+
+	return doctorCmd
+}
+
+func runCmdDoctor(ctx context.Context, owner string, g preflight.Getter, usesApp bool) error {
+	zap.S().Debugf("Running preflight checks for %s", owner)
+	report := preflight.Run(ctx, g, owner, nil, usesApp)
+	if err := report.Write(os.Stdout); err != nil {
+		return err
+	}
+	return report.Err()
+}
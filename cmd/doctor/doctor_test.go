@@ -0,0 +1,44 @@
+package doctor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+)
+
+func TestNewCmdDoctor(t *testing.T) {
+	cmd := NewCmdDoctor()
+
+	if cmd == nil {
+		t.Fatal("NewCmdDoctor() returned nil")
+	}
+
+	if cmd.Use != "doctor [<organization>] [flags]" {
+		t.Errorf("Expected Use to be 'doctor [<organization>] [flags]', got %s", cmd.Use)
+	}
+
+	for _, name := range []string{"token", "hostname", "app-id", "app-private-key", "installation-id", "debug"} {
+		if cmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+}
+
+func TestRunCmdDoctor(t *testing.T) {
+	mockGetter := data.NewMockAPIGetter()
+	mockGetter.TokenScopes = []string{"admin:org_hook", "repo"}
+	mockGetter.TokenScopesKnown = true
+	mockGetter.OrganizationData = []byte(`{"login":"test-org","id":1}`)
+	mockGetter.MembershipData = []byte(`{"state":"active","role":"admin"}`)
+	mockGetter.OrganizationWebhooksData = []byte(`[]`)
+
+	if err := runCmdDoctor(context.Background(), "test-org", mockGetter, false); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	mockGetter.MembershipData = []byte(`{"state":"active","role":"member"}`)
+	if err := runCmdDoctor(context.Background(), "test-org", mockGetter, false); err == nil {
+		t.Error("Expected error for non-owner membership, got nil")
+	}
+}
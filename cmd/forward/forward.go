@@ -0,0 +1,292 @@
+package forward
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-organization-webhooks/internal/client"
+	"github.com/katiem0/gh-organization-webhooks/internal/config"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/log"
+	"github.com/katiem0/gh-organization-webhooks/internal/output"
+	"github.com/katiem0/gh-organization-webhooks/internal/relay"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type forwardCmdFlags struct {
+	token          string
+	hostname       string
+	appID          int64
+	appPrivateKey  string
+	installationID int64
+	hookID         int64
+	url            string
+	secret         string
+	stateFile      string
+	interval       time.Duration
+	targetTimeout  time.Duration
+	timeout        time.Duration
+	requestTimeout time.Duration
+	maxAttempts    int
+	debug          bool
+}
+
+type deliveryGetter interface {
+	GetHookDelivery(ctx context.Context, owner string, hookID, deliveryID int64) ([]byte, error)
+	GetHookDeliveries(ctx context.Context, owner string, hookID int64, cursor string) ([]byte, string, error)
+}
+
+// forwardState is persisted between runs so deliveries are forwarded once
+type forwardState struct {
+	Organization   string `json:"organization"`
+	HookID         int64  `json:"hook_id"`
+	LastDeliveryID int64  `json:"last_delivery_id"`
+}
+
+type forwarder struct {
+	owner      string
+	hookID     int64
+	getter     deliveryGetter
+	httpClient *http.Client
+	url        string
+	secret     []byte
+	statePath  string
+	state      forwardState
+	out        io.Writer
+	// maxAttempts is how often a delivery is tried before it is skipped, 0 retries forever
+	maxAttempts int
+	// failures counts the failed attempts of the oldest unforwarded delivery
+	failures int
+}
+
+func NewCmdForward() *cobra.Command {
+	forwardCmdFlags := forwardCmdFlags{}
+
+	forwardCmd := &cobra.Command{
+		Use:   "forward [<organization>] [flags]",
+		Short: "Forward new webhook deliveries to a local URL",
+		Long:  "Poll an organization webhook's deliveries and forward each new delivery to a URL such as a local service, re-signed with a local secret, without requiring inbound connectivity",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(forwardCmd *cobra.Command, args []string) error {
+			var err error
+			var restClient *api.RESTClient
+
+			// Reinitialize logging if debugging was enabled
+			if forwardCmdFlags.debug {
+				logger, _ := log.NewLogger(forwardCmdFlags.debug)
+				defer logger.Sync() // nolint:errcheck
+				zap.ReplaceGlobals(logger)
+			}
+
+			if forwardCmdFlags.secret == "" {
+				forwardCmdFlags.secret = os.Getenv("WEBHOOK_SECRET")
+			}
+			if forwardCmdFlags.interval <= 0 {
+				return errors.New("--interval must be greater than zero")
+			}
+			if forwardCmdFlags.maxAttempts < 0 {
+				return errors.New("--max-attempts must not be negative")
+			}
+
+			restClient, err = client.NewRESTClient(client.Options{
+				Hostname:       forwardCmdFlags.hostname,
+				Token:          forwardCmdFlags.token,
+				AppID:          forwardCmdFlags.appID,
+				AppPrivateKey:  forwardCmdFlags.appPrivateKey,
+				InstallationID: forwardCmdFlags.installationID,
+				RequestTimeout: forwardCmdFlags.requestTimeout,
+			})
+			if err != nil {
+				zap.S().Errorf("Error arose retrieving rest client: %v", err)
+				return err
+			}
+
+			owner, err := config.FromContext(forwardCmd.Context()).OrganizationArg(args, 0)
+			if err != nil {
+				return err
+			}
+			statePath := forwardCmdFlags.stateFile
+			if statePath == "" {
+				statePath = fmt.Sprintf(".forward-%s-%d.json", owner, forwardCmdFlags.hookID)
+			}
+			state, err := loadState(statePath, owner, forwardCmdFlags.hookID)
+			if err != nil {
+				return err
+			}
+
+			f := &forwarder{
+				owner:       owner,
+				hookID:      forwardCmdFlags.hookID,
+				getter:      data.NewAPIGetter(restClient),
+				httpClient:  &http.Client{Timeout: forwardCmdFlags.targetTimeout},
+				url:         forwardCmdFlags.url,
+				secret:      []byte(forwardCmdFlags.secret),
+				statePath:   statePath,
+				state:       state,
+				out:         os.Stdout,
+				maxAttempts: forwardCmdFlags.maxAttempts,
+			}
+
+			ctx, cancel := client.CommandContext(forwardCmdFlags.timeout)
+			defer cancel()
+
+			return runCmdForward(ctx, f, forwardCmdFlags.interval)
+		},
+	}
+
+	// Configure flags for command
+	forwardCmd.PersistentFlags().StringVarP(&forwardCmdFlags.token, "token", "t", "", `GitHub personal access token for reading the organization (default "gh auth token")`)
+	forwardCmd.PersistentFlags().StringVarP(&forwardCmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
+	forwardCmd.PersistentFlags().Int64VarP(&forwardCmdFlags.appID, "app-id", "", 0, "GitHub App ID used to authenticate instead of a token (Requires --app-private-key and --installation-id)")
+	forwardCmd.PersistentFlags().StringVarP(&forwardCmdFlags.appPrivateKey, "app-private-key", "", "", "Path to the GitHub App private key PEM file")
+	forwardCmd.PersistentFlags().Int64VarP(&forwardCmdFlags.installationID, "installation-id", "", 0, "GitHub App installation ID for the organization")
+	forwardCmd.Flags().Int64VarP(&forwardCmdFlags.hookID, "hook-id", "", 0, "ID of the organization webhook to forward deliveries from")
+	forwardCmd.Flags().StringVarP(&forwardCmdFlags.url, "url", "u", "", "URL to POST deliveries to (e.g. http://localhost:8080)")
+	forwardCmd.Flags().StringVarP(&forwardCmdFlags.secret, "secret", "s", "", `Secret used to re-sign payloads (default "$WEBHOOK_SECRET")`)
+	forwardCmd.Flags().StringVarP(&forwardCmdFlags.stateFile, "state-file", "", "", `File recording the last forwarded delivery (default ".forward-<organization>-<hook-id>.json")`)
+	forwardCmd.Flags().DurationVarP(&forwardCmdFlags.interval, "interval", "i", 10*time.Second, "How often to poll for new deliveries")
+	forwardCmd.Flags().DurationVarP(&forwardCmdFlags.targetTimeout, "target-timeout", "", 30*time.Second, "Maximum time for each request to the URL")
+	client.AddTimeoutFlags(forwardCmd.PersistentFlags(), &forwardCmdFlags.timeout, &forwardCmdFlags.requestTimeout)
+	forwardCmd.Flags().IntVarP(&forwardCmdFlags.maxAttempts, "max-attempts", "", 0, "Polls a failing delivery is retried on before it is skipped (0 retries until it succeeds)")
+	forwardCmd.PersistentFlags().BoolVarP(&forwardCmdFlags.debug, "debug", "d", false, "To debug logging")
+
+	_ = forwardCmd.MarkFlagRequired("hook-id")
+	_ = forwardCmd.MarkFlagRequired("url")
+
+	return forwardCmd
+}
+
+func runCmdForward(ctx context.Context, f *forwarder, interval time.Duration) error {
+	if len(f.secret) == 0 {
+		zap.S().Warn("No secret configured, deliveries will be forwarded without a signature")
+	}
+	fmt.Fprintf(os.Stderr, "Forwarding deliveries of hook %d in %s to %s every %s (Ctrl-C to stop)\n", f.hookID, f.owner, f.url, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := f.poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			zap.S().Warnf("Error polling deliveries: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll forwards every delivery newer than the last forwarded one, oldest
+// first, stopping at the first delivery that can't be forwarded. The first poll
+// without saved state only records the newest delivery.
+func (f *forwarder) poll(ctx context.Context) error {
+	// Without saved state only the newest page is needed
+	maxPages := 0
+	if f.state.LastDeliveryID == 0 {
+		maxPages = 1
+	}
+	deliveries, err := data.DeliveriesAfter(ctx, f.getter, f.owner, f.hookID, f.state.LastDeliveryID, maxPages)
+	if err != nil {
+		return err
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if f.state.LastDeliveryID == 0 {
+		newest := deliveries[len(deliveries)-1]
+		zap.S().Debugf("No saved state, starting after delivery %d", newest.ID)
+		f.state.LastDeliveryID = newest.ID
+		return saveState(f.statePath, f.state)
+	}
+
+	for _, d := range deliveries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := f.forward(ctx, d.ID); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			// Stop without advancing the state so the next poll retries the
+			// delivery, unless it has failed too often
+			f.failures++
+			if f.maxAttempts == 0 || f.failures < f.maxAttempts {
+				return fmt.Errorf("forwarding delivery %d (attempt %d): %w", d.ID, f.failures, err)
+			}
+			zap.S().Warnf("Skipping delivery %d after %d failed attempts: %v", d.ID, f.failures, err)
+		}
+		f.failures = 0
+		f.state.LastDeliveryID = d.ID
+		if err := saveState(f.statePath, f.state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *forwarder) forward(ctx context.Context, deliveryID int64) error {
+	raw, err := f.getter.GetHookDelivery(ctx, f.owner, f.hookID, deliveryID)
+	if err != nil {
+		return err
+	}
+	var d data.HookDelivery
+	if err := json.Unmarshal(raw, &d); err != nil {
+		return err
+	}
+
+	result, err := relay.Send(ctx, f.httpClient, f.url, d, f.secret)
+	if err != nil {
+		return err
+	}
+	if result.StatusCode < http.StatusOK || result.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s responded %d %s", f.url, result.StatusCode, http.StatusText(result.StatusCode))
+	}
+	fmt.Fprintf(f.out, "%s Forwarded %s delivery %d (%s): %d %s in %s\n",
+		time.Now().Format(time.RFC3339), d.Event, d.ID, d.GUID,
+		result.StatusCode, http.StatusText(result.StatusCode), result.Duration.Round(time.Millisecond))
+	return nil
+}
+
+// loadState reads the saved state, starting fresh when the file does not
+// exist or belongs to a different hook.
+func loadState(path, owner string, hookID int64) (forwardState, error) {
+	state := forwardState{Organization: owner, HookID: hookID}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+
+	var saved forwardState
+	if err := json.Unmarshal(raw, &saved); err != nil {
+		return state, fmt.Errorf("unable to read state file %s: %w", path, err)
+	}
+	if saved.Organization != owner || saved.HookID != hookID {
+		zap.S().Warnf("State file %s is for hook %d in %s, starting fresh", path, saved.HookID, saved.Organization)
+		return state, nil
+	}
+	return saved, nil
+}
+
+// saveState writes the state file
+func saveState(path string, state forwardState) error {
+	raw, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return output.WriteFileAtomic(path, append(raw, '\n'), 0o644)
+}
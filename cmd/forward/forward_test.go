@@ -0,0 +1,204 @@
+package forward
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-organization-webhooks/pkg/signature"
+)
+
+// fakeDeliveries serves deliveries with the given IDs, newest first, two per page
+type fakeDeliveries struct {
+	ids     []int64
+	fetched []int64
+}
+
+func (f *fakeDeliveries) GetHookDeliveries(ctx context.Context, owner string, hookID int64, cursor string) ([]byte, string, error) {
+	start := 0
+	if cursor != "" {
+		_, _ = fmt.Sscanf(cursor, "%d", &start)
+	}
+	end := min(start+2, len(f.ids))
+	var items []string
+	for _, id := range f.ids[start:end] {
+		items = append(items, fmt.Sprintf(`{"id":%d,"event":"push"}`, id))
+	}
+	next := ""
+	if end < len(f.ids) {
+		next = fmt.Sprint(end)
+	}
+	return []byte("[" + strings.Join(items, ",") + "]"), next, nil
+}
+
+func (f *fakeDeliveries) GetHookDelivery(ctx context.Context, owner string, hookID, deliveryID int64) ([]byte, error) {
+	f.fetched = append(f.fetched, deliveryID)
+	return []byte(fmt.Sprintf(`{"id":%d,"event":"push","request":{"headers":{"X-GitHub-Event":"push"},"payload":{"id":%d}}}`, deliveryID, deliveryID)), nil
+}
+
+func TestNewCmdForward(t *testing.T) {
+	cmd := NewCmdForward()
+
+	if cmd == nil {
+		t.Fatal("NewCmdForward() returned nil")
+	}
+
+	if cmd.Use != "forward [<organization>] [flags]" {
+		t.Errorf("Expected Use to be 'forward [<organization>] [flags]', got %s", cmd.Use)
+	}
+
+	for _, name := range []string{"token", "hostname", "hook-id", "url", "secret", "state-file", "interval", "target-timeout", "timeout", "request-timeout", "max-attempts", "debug"} {
+		if cmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+}
+
+func TestForwarderPoll(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := signature.VerifyHeaders([]byte("local"), body, r.Header); err != nil {
+			t.Errorf("Forwarded delivery was not re-signed: %v", err)
+		}
+		received = append(received, string(body))
+	}))
+	defer server.Close()
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	getter := &fakeDeliveries{ids: []int64{3, 2, 1}}
+	newForwarder := func() *forwarder {
+		state, err := loadState(statePath, "test-org", 7)
+		if err != nil {
+			t.Fatalf("loadState() error = %v", err)
+		}
+		return &forwarder{
+			owner: "test-org", hookID: 7, getter: getter, httpClient: server.Client(),
+			url: server.URL, secret: []byte("local"), statePath: statePath, state: state, out: &bytes.Buffer{},
+		}
+	}
+
+	// The first poll only records where to start
+	f := newForwarder()
+	if err := f.poll(context.Background()); err != nil {
+		t.Fatalf("poll() error = %v", err)
+	}
+	if len(received) != 0 || f.state.LastDeliveryID != 3 {
+		t.Fatalf("Expected no forwards and last ID 3, got %v and %d", received, f.state.LastDeliveryID)
+	}
+
+	// New deliveries spanning several pages are forwarded oldest first
+	getter.ids = []int64{7, 6, 5, 4, 3, 2, 1}
+	if err := f.poll(context.Background()); err != nil {
+		t.Fatalf("poll() error = %v", err)
+	}
+	want := []string{`{"id":4}`, `{"id":5}`, `{"id":6}`, `{"id":7}`}
+	if strings.Join(received, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected forwards %v, got %v", want, received)
+	}
+
+	// A restarted forwarder resumes from the state file without duplicates
+	getter.ids = []int64{8, 7, 6}
+	received = nil
+	f = newForwarder()
+	if f.state.LastDeliveryID != 7 {
+		t.Fatalf("Expected saved last ID 7, got %d", f.state.LastDeliveryID)
+	}
+	if err := f.poll(context.Background()); err != nil {
+		t.Fatalf("poll() error = %v", err)
+	}
+	if len(received) != 1 || received[0] != `{"id":8}` {
+		t.Errorf("Expected only delivery 8 to be forwarded, got %v", received)
+	}
+}
+
+func TestForwarderPollRetriesFailures(t *testing.T) {
+	for _, status := range []int{http.StatusInternalServerError, http.StatusUnauthorized, http.StatusNotFound, http.StatusGone} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var received []string
+			failing := map[string]int{`{"id":2}`: 1}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if failing[string(body)] > 0 {
+					failing[string(body)]--
+					w.WriteHeader(status)
+					return
+				}
+				received = append(received, string(body))
+			}))
+			defer server.Close()
+
+			statePath := filepath.Join(t.TempDir(), "state.json")
+			f := &forwarder{
+				owner: "test-org", hookID: 7, getter: &fakeDeliveries{ids: []int64{3, 2, 1}}, httpClient: server.Client(),
+				url: server.URL, statePath: statePath, state: forwardState{LastDeliveryID: 1}, out: &bytes.Buffer{},
+			}
+
+			// The failed delivery stops the poll without advancing the state
+			if err := f.poll(context.Background()); err == nil || !strings.Contains(err.Error(), "delivery 2") {
+				t.Fatalf("Expected error forwarding delivery 2, got %v", err)
+			}
+			if len(received) != 0 || f.state.LastDeliveryID != 1 {
+				t.Fatalf("Expected no forwards and last ID 1, got %v and %d", received, f.state.LastDeliveryID)
+			}
+
+			// The next poll retries it before the newer delivery
+			if err := f.poll(context.Background()); err != nil {
+				t.Fatalf("poll() error = %v", err)
+			}
+			if strings.Join(received, " ") != `{"id":2} {"id":3}` || f.state.LastDeliveryID != 3 {
+				t.Errorf("Expected deliveries 2 and 3 with last ID 3, got %v and %d", received, f.state.LastDeliveryID)
+			}
+		})
+	}
+}
+
+func TestForwarderPollSkipsAfterMaxAttempts(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) == `{"id":2}` {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		received = append(received, string(body))
+	}))
+	defer server.Close()
+
+	f := &forwarder{
+		owner: "test-org", hookID: 7, getter: &fakeDeliveries{ids: []int64{3, 2, 1}}, httpClient: server.Client(),
+		url: server.URL, statePath: filepath.Join(t.TempDir(), "state.json"), state: forwardState{LastDeliveryID: 1},
+		out: &bytes.Buffer{}, maxAttempts: 2,
+	}
+
+	if err := f.poll(context.Background()); err == nil {
+		t.Fatal("Expected the first attempt to fail")
+	}
+	if err := f.poll(context.Background()); err != nil {
+		t.Fatalf("poll() error = %v", err)
+	}
+	if len(received) != 1 || received[0] != `{"id":3}` || f.state.LastDeliveryID != 3 {
+		t.Errorf("Expected delivery 2 to be skipped, got %v and last ID %d", received, f.state.LastDeliveryID)
+	}
+}
+
+func TestLoadStateOtherHook(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	if err := saveState(statePath, forwardState{Organization: "other-org", HookID: 1, LastDeliveryID: 99}); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := loadState(statePath, "test-org", 7)
+	if err != nil {
+		t.Fatalf("loadState() error = %v", err)
+	}
+	if state.LastDeliveryID != 0 || state.Organization != "test-org" {
+		t.Errorf("Expected fresh state, got %+v", state)
+	}
+}
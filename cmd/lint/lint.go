@@ -0,0 +1,142 @@
+package lint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-organization-webhooks/internal/client"
+	"github.com/katiem0/gh-organization-webhooks/internal/config"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/lint"
+	"github.com/katiem0/gh-organization-webhooks/internal/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type lintCmdFlags struct {
+	token          string
+	hostname       string
+	appID          int64
+	appPrivateKey  string
+	installationID int64
+	format         string
+	outputFile     string
+	failLevel      string
+	timeout        time.Duration
+	requestTimeout time.Duration
+	debug          bool
+}
+
+type webhookGetter interface {
+	GetOrganizationWebhooks(ctx context.Context, owner string) ([]byte, error)
+}
+
+func NewCmdLint() *cobra.Command {
+	lintCmdFlags := lintCmdFlags{}
+
+	lintCmd := &cobra.Command{
+		Use:   "lint [<organization>] [flags]",
+		Short: "Check organization level webhooks against security rules",
+		Long:  "Check organization level webhooks against security rules, reporting findings as text, JSON or SARIF",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(lintCmd *cobra.Command, args []string) error {
+			var err error
+			var restClient *api.RESTClient
+
+			// Reinitialize logging if debugging was enabled
+			if lintCmdFlags.debug {
+				logger, _ := log.NewLogger(lintCmdFlags.debug)
+				defer logger.Sync() // nolint:errcheck
+				zap.ReplaceGlobals(logger)
+			}
+
+			failLevel, err := lint.ParseLevel(lintCmdFlags.failLevel)
+			if err != nil {
+				return err
+			}
+
+			restClient, err = client.NewRESTClient(client.Options{
+				Hostname:       lintCmdFlags.hostname,
+				Token:          lintCmdFlags.token,
+				AppID:          lintCmdFlags.appID,
+				AppPrivateKey:  lintCmdFlags.appPrivateKey,
+				InstallationID: lintCmdFlags.installationID,
+				RequestTimeout: lintCmdFlags.requestTimeout,
+			})
+			if err != nil {
+				zap.S().Errorf("Error arose retrieving rest client: %v", err)
+				return err
+			}
+
+			ctx, cancel := client.CommandContext(lintCmdFlags.timeout)
+			defer cancel()
+
+			owner, err := config.FromContext(lintCmd.Context()).OrganizationArg(args, 0)
+			if err != nil {
+				return err
+			}
+
+			var reportWriter io.Writer = os.Stdout
+			if lintCmdFlags.outputFile != "" {
+				f, err := os.Create(lintCmdFlags.outputFile)
+				if err != nil {
+					zap.S().Errorf("Error opening file: %v", err)
+					return err
+				}
+				defer func() {
+					if err := f.Close(); err != nil {
+						zap.S().Errorf("Error closing file: %v", err)
+					}
+				}()
+				reportWriter = f
+			}
+
+			return runCmdLint(ctx, owner, data.NewAPIGetter(restClient), reportWriter, lintCmdFlags.format, failLevel)
+		},
+	}
+
+	// Configure flags for command
+	lintCmd.PersistentFlags().StringVarP(&lintCmdFlags.token, "token", "t", "", `GitHub personal access token for reading the organization (default "gh auth token")`)
+	lintCmd.PersistentFlags().StringVarP(&lintCmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
+	lintCmd.PersistentFlags().Int64VarP(&lintCmdFlags.appID, "app-id", "", 0, "GitHub App ID used to authenticate instead of a token (Requires --app-private-key and --installation-id)")
+	lintCmd.PersistentFlags().StringVarP(&lintCmdFlags.appPrivateKey, "app-private-key", "", "", "Path to the GitHub App private key PEM file")
+	lintCmd.PersistentFlags().Int64VarP(&lintCmdFlags.installationID, "installation-id", "", 0, "GitHub App installation ID for the organization")
+	lintCmd.Flags().StringVarP(&lintCmdFlags.format, "format", "", "text", fmt.Sprintf("Output format of the findings: {%s}", strings.Join(lint.Formats, "|")))
+	lintCmd.Flags().StringVarP(&lintCmdFlags.outputFile, "output-file", "o", "", "Name of file to write findings to (default stdout)")
+	lintCmd.Flags().StringVarP(&lintCmdFlags.failLevel, "fail-level", "", "error", "Exit with an error when findings are at least this level: {error|warning|note|none}")
+	client.AddTimeoutFlags(lintCmd.PersistentFlags(), &lintCmdFlags.timeout, &lintCmdFlags.requestTimeout)
+	lintCmd.PersistentFlags().BoolVarP(&lintCmdFlags.debug, "debug", "d", false, "To debug logging")
+
+	return lintCmd
+}
+
+func runCmdLint(ctx context.Context, owner string, g webhookGetter, w io.Writer, format string, failLevel lint.Level) error {
+	zap.S().Debugf("Gathering Webhooks for %s", owner)
+	orgWebhooks, err := g.GetOrganizationWebhooks(ctx, owner)
+	if err != nil {
+		zap.S().Errorf("Error authenticating and getting response from webhooks endpoint for %v", owner)
+		return err
+	}
+
+	var responseWebhooks []data.Webhook
+	if err := json.Unmarshal(orgWebhooks, &responseWebhooks); err != nil {
+		return err
+	}
+
+	zap.S().Debugf("Evaluating %d webhook(s) against %d rule(s)", len(responseWebhooks), len(lint.Rules))
+	findings := lint.Lint(owner, responseWebhooks)
+	if err := lint.Write(w, format, findings); err != nil {
+		return err
+	}
+
+	if lint.Exceeds(findings, failLevel) {
+		return fmt.Errorf("webhooks for %s have findings at or above the %s level", owner, failLevel)
+	}
+	return nil
+}
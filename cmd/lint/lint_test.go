@@ -0,0 +1,53 @@
+package lint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/lint"
+)
+
+func TestNewCmdLint(t *testing.T) {
+	cmd := NewCmdLint()
+
+	if cmd == nil {
+		t.Fatal("NewCmdLint() returned nil")
+	}
+
+	if cmd.Use != "lint [<organization>] [flags]" {
+		t.Errorf("Expected Use to be 'lint [<organization>] [flags]', got %s", cmd.Use)
+	}
+
+	for _, name := range []string{"token", "hostname", "format", "output-file", "fail-level"} {
+		if cmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+}
+
+func TestRunCmdLint(t *testing.T) {
+	webhooks := []data.Webhook{
+		{ID: 1, Active: true, Events: []string{"push"}, Config: data.Config{InsecureSSL: "0", Secret: "********", Url: "https://example.com/hook"}},
+		{ID: 2, Active: true, Events: []string{"*"}, Config: data.Config{InsecureSSL: "0", Secret: "********", Url: "https://example.com/all"}},
+	}
+	mockGetter := data.NewMockAPIGetter()
+	mockGetter.OrganizationWebhooksData, _ = json.Marshal(webhooks)
+
+	var buf bytes.Buffer
+	if err := runCmdLint(context.Background(), "test-org", mockGetter, &buf, "json", lint.LevelError); err != nil {
+		t.Errorf("Expected warnings not to fail at error level, got %v", err)
+	}
+
+	var findings []lint.Finding
+	if err := json.Unmarshal(buf.Bytes(), &findings); err != nil || len(findings) != 1 || findings[0].HookID != 2 {
+		t.Errorf("Unexpected findings %s (%v)", buf.String(), err)
+	}
+
+	buf.Reset()
+	if err := runCmdLint(context.Background(), "test-org", mockGetter, &buf, "json", lint.LevelWarning); err == nil {
+		t.Error("Expected warnings to fail at warning level, got nil")
+	}
+}
@@ -1,40 +1,63 @@
 package list
 
 import (
-	"encoding/csv"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
-	"strconv"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/cli/go-gh/v2/pkg/api"
-	"github.com/cli/go-gh/v2/pkg/auth"
+	"github.com/katiem0/gh-organization-webhooks/internal/client"
+	"github.com/katiem0/gh-organization-webhooks/internal/config"
 	"github.com/katiem0/gh-organization-webhooks/internal/data"
 	"github.com/katiem0/gh-organization-webhooks/internal/log"
+	"github.com/katiem0/gh-organization-webhooks/internal/output"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
 type listCmdFlags struct {
-	token    string
-	hostname string
-	listFile string
-	debug    bool
+	token          string
+	hostname       string
+	appID          int64
+	appPrivateKey  string
+	installationID int64
+	listFile       string
+	format         string
+	template       string
+	jq             string
+	active         bool
+	inactive       bool
+	events         []string
+	urlMatch       string
+	contentType    string
+	insecureSSL    bool
+	createdBefore  string
+	updatedSince   string
+	timeout        time.Duration
+	requestTimeout time.Duration
+	debug          bool
 }
 
 func NewCmdList() *cobra.Command {
 	listCmdFlags := listCmdFlags{}
-	var authToken string
 
 	listCmd := &cobra.Command{
-		Use:   "list <source organization> [flags]",
+		Use:   "list [<source organization>] [flags]",
 		Short: "List organization level webhooks",
 		Long:  "List organization level webhooks",
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MaximumNArgs(1),
+		PreRunE: func(listCmd *cobra.Command, args []string) error {
+			if listCmdFlags.active && listCmdFlags.inactive {
+				return errors.New("specify only one of `--active` or `--inactive`")
+			}
+			return nil
+		},
 		RunE: func(listCmd *cobra.Command, args []string) error {
 
 			var err error
@@ -47,19 +70,13 @@ func NewCmdList() *cobra.Command {
 				zap.ReplaceGlobals(logger)
 			}
 
-			if listCmdFlags.token != "" {
-				authToken = listCmdFlags.token
-			} else {
-				t, _ := auth.TokenForHost(listCmdFlags.hostname)
-				authToken = t
-			}
-
-			restClient, err = api.NewRESTClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github+json",
-				},
-				Host:      listCmdFlags.hostname,
-				AuthToken: authToken,
+			restClient, err = client.NewRESTClient(client.Options{
+				Hostname:       listCmdFlags.hostname,
+				Token:          listCmdFlags.token,
+				AppID:          listCmdFlags.appID,
+				AppPrivateKey:  listCmdFlags.appPrivateKey,
+				InstallationID: listCmdFlags.installationID,
+				RequestTimeout: listCmdFlags.requestTimeout,
 			})
 
 			if err != nil {
@@ -67,13 +84,34 @@ func NewCmdList() *cobra.Command {
 				return err
 			}
 
-			owner := args[0]
+			ctx, cancel := client.CommandContext(listCmdFlags.timeout)
+			defer cancel()
+
+			owner, err := config.FromContext(listCmd.Context()).OrganizationArg(args, 0)
+			if err != nil {
+				return err
+			}
+
+			filter, err := listCmdFlags.webhookFilter()
+			if err != nil {
+				return err
+			}
+
+			writer, err := listCmdFlags.outputWriter()
+			if err != nil {
+				return err
+			}
+
+			listCmdFlags.listFile = listCmdFlags.outputFile(listCmd)
+			if listCmdFlags.listFile == "" {
+				return runCmdList(ctx, owner, data.NewAPIGetter(restClient), os.Stdout, writer, filter)
+			}
 
 			if _, err := os.Stat(listCmdFlags.listFile); errors.Is(err, os.ErrExist) {
 				return err
 			}
 
-			reportWriter, err := os.OpenFile(listCmdFlags.listFile, os.O_WRONLY|os.O_CREATE, 0644)
+			reportWriter, err := os.OpenFile(listCmdFlags.listFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 			if err != nil {
 				zap.S().Errorf("Error opening file: %v", err)
 				return err
@@ -84,7 +122,12 @@ func NewCmdList() *cobra.Command {
 				}
 			}()
 
-			return runCmdList(owner, data.NewAPIGetter(restClient), reportWriter)
+			err = runCmdList(ctx, owner, data.NewAPIGetter(restClient), reportWriter, writer, filter)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Successfully listed organizational webhooks for %s", owner)
+			return nil
 		},
 	}
 
@@ -94,37 +137,83 @@ func NewCmdList() *cobra.Command {
 
 	listCmd.PersistentFlags().StringVarP(&listCmdFlags.token, "token", "t", "", `GitHub personal access token for reading source organization (default "gh auth token")`)
 	listCmd.PersistentFlags().StringVarP(&listCmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
-	listCmd.Flags().StringVarP(&listCmdFlags.listFile, "output-file", "o", reportFileDefault, "Name of file to write CSV list to")
+	listCmd.PersistentFlags().Int64VarP(&listCmdFlags.appID, "app-id", "", 0, "GitHub App ID used to authenticate instead of a token (Requires --app-private-key and --installation-id)")
+	listCmd.PersistentFlags().StringVarP(&listCmdFlags.appPrivateKey, "app-private-key", "", "", "Path to the GitHub App private key PEM file")
+	listCmd.PersistentFlags().Int64VarP(&listCmdFlags.installationID, "installation-id", "", 0, "GitHub App installation ID for the organization")
+	listCmd.Flags().StringVarP(&listCmdFlags.listFile, "output-file", "o", reportFileDefault, "Name of file to write the report to")
+	listCmd.Flags().StringVarP(&listCmdFlags.format, "format", "", "csv", fmt.Sprintf("Output format of the report: {%s}", strings.Join(output.Formats(), "|")))
+	listCmd.Flags().StringVarP(&listCmdFlags.template, "template", "", "", "Format the webhooks using a Go template, written to stdout unless --output-file is set")
+	listCmd.Flags().StringVarP(&listCmdFlags.jq, "jq", "q", "", "Select values from the JSON webhooks using a jq expression, written to stdout unless --output-file is set")
+	listCmd.MarkFlagsMutuallyExclusive("format", "template", "jq")
+	listCmd.Flags().BoolVarP(&listCmdFlags.active, "active", "", false, "Only list webhooks that are active")
+	listCmd.Flags().BoolVarP(&listCmdFlags.inactive, "inactive", "", false, "Only list webhooks that are inactive")
+	listCmd.Flags().StringSliceVarP(&listCmdFlags.events, "event", "", nil, "Only list webhooks subscribed to the event, including webhooks subscribed to all events (*)")
+	listCmd.Flags().StringVarP(&listCmdFlags.urlMatch, "url-match", "", "", "Only list webhooks with a URL matching the regular expression")
+	listCmd.Flags().StringVarP(&listCmdFlags.contentType, "content-type", "", "", "Only list webhooks with the content type (json or form)")
+	listCmd.Flags().BoolVarP(&listCmdFlags.insecureSSL, "insecure-ssl", "", false, "Only list webhooks that do not verify SSL certificates")
+	listCmd.Flags().StringVarP(&listCmdFlags.createdBefore, "created-before", "", "", "Only list webhooks created before the date (YYYY-MM-DD or RFC3339)")
+	listCmd.Flags().StringVarP(&listCmdFlags.updatedSince, "updated-since", "", "", "Only list webhooks updated on or after the date (YYYY-MM-DD or RFC3339)")
+	client.AddTimeoutFlags(listCmd.PersistentFlags(), &listCmdFlags.timeout, &listCmdFlags.requestTimeout)
 	listCmd.PersistentFlags().BoolVarP(&listCmdFlags.debug, "debug", "d", false, "To debug logging")
 
 	return listCmd
 }
 
-func runCmdList(owner string, g *data.APIGetter, reportWriter io.Writer) error {
-	csvWriter := csv.NewWriter(reportWriter)
-
-	err := csvWriter.Write([]string{
-		"Type",
-		"ID",
-		"Name",
-		"Active",
-		"Events",
-		"Config_ContentType",
-		"Config_InsecureSSL",
-		"Config_Secret",
-		"Config_URL",
-		"Updated_At",
-		"Created_At",
-	})
+// outputFile returns the file to write the webhooks to, or "" to write template
+// and jq output to stdout. A file given on the command line or in the profile
+// is used as is.
+func (f *listCmdFlags) outputFile(cmd *cobra.Command) string {
+	if config.IsSet(cmd, "output-file") {
+		return f.listFile
+	}
+	if f.template != "" || f.jq != "" {
+		return ""
+	}
+	return fmt.Sprintf("%s.%s", strings.TrimSuffix(f.listFile, ".csv"), output.Extension(f.format))
+}
 
-	if err != nil {
-		return err
+func (f *listCmdFlags) outputWriter() (output.Writer, error) {
+	switch {
+	case f.template != "":
+		return output.NewTemplateWriter(f.template)
+	case f.jq != "":
+		return output.NewJQWriter(f.jq), nil
+	default:
+		return output.NewWriter(f.format)
+	}
+}
+
+func (f *listCmdFlags) webhookFilter() (data.WebhookFilter, error) {
+	var err error
+	filter := data.WebhookFilter{
+		Events:      f.events,
+		ContentType: f.contentType,
+		InsecureSSL: f.insecureSSL,
+	}
+	if f.active || f.inactive {
+		active := f.active
+		filter.Active = &active
+	}
+	if f.urlMatch != "" {
+		filter.URLMatch, err = regexp.Compile(f.urlMatch)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --url-match expression: %w", err)
+		}
+	}
+	if filter.CreatedBefore, err = data.ParseFilterTime(f.createdBefore); err != nil {
+		return filter, err
 	}
+	if filter.UpdatedSince, err = data.ParseFilterTime(f.updatedSince); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
 
+func runCmdList(ctx context.Context, owner string, g *data.APIGetter, reportWriter io.Writer, writer output.Writer, filter data.WebhookFilter) error {
 	zap.S().Debugf("Gathering Webooks for %s", owner)
-	orgWebhooks, err := g.GetOrganizationWebhooks(owner)
+	orgWebhooks, err := g.GetOrganizationWebhooks(ctx, owner)
 	if err != nil {
-		zap.S().Errorf("Error authenticating and getting response from webhooks endpoint for %v", owner)
+		zap.S().Debugf("Error getting response from webhooks endpoint for %v", owner)
 		return err
 	}
 
@@ -133,28 +222,13 @@ func runCmdList(owner string, g *data.APIGetter, reportWriter io.Writer) error {
 	if err != nil {
 		return err
 	}
+	responseWebhooks = data.FilterWebhooks(responseWebhooks, filter)
 	zap.S().Debugf("Writing data for %d webhook(s) to output for organization %s", len(responseWebhooks), owner)
-	for _, webhook := range responseWebhooks {
-		err = csvWriter.Write([]string{
-			webhook.HookType,
-			strconv.Itoa(webhook.ID),
-			webhook.Name,
-			strconv.FormatBool(webhook.Active),
-			fmt.Sprint(strings.Join(webhook.Events, ";")),
-			webhook.Config.ContentType,
-			webhook.Config.InsecureSSL,
-			webhook.Config.Secret,
-			webhook.Config.Url,
-			webhook.UpdatedAt.Format(time.RFC3339),
-			webhook.CreatedAt.Format(time.RFC3339),
-		})
-
-		if err != nil {
-			zap.S().Error("Error raised in writing output", zap.Error(err))
-		}
+	err = writer.Write(reportWriter, owner, responseWebhooks)
+	if err != nil {
+		zap.S().Error("Error raised in writing output", zap.Error(err))
+		return err
 	}
-	fmt.Printf("Successfully listed organizational webhooks for %s", owner)
-	csvWriter.Flush()
 
 	return nil
 }
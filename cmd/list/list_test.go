@@ -2,6 +2,9 @@ package list
 
 import (
 	"testing"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/config"
+	"github.com/spf13/cobra"
 )
 
 func TestNewCmdList(t *testing.T) {
@@ -12,8 +15,8 @@ func TestNewCmdList(t *testing.T) {
 	}
 
 	// Test basic properties
-	if cmd.Use != "list <source organization> [flags]" {
-		t.Errorf("Expected Use to be 'list <source organization> [flags]', got %s", cmd.Use)
+	if cmd.Use != "list [<source organization>] [flags]" {
+		t.Errorf("Expected Use to be 'list [<source organization>] [flags]', got %s", cmd.Use)
 	}
 
 	// Test flags
@@ -29,8 +32,82 @@ func TestNewCmdList(t *testing.T) {
 		t.Error("token flag not found")
 	}
 
+	for _, name := range []string{"app-id", "app-private-key", "installation-id", "format"} {
+		if cmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+
 	// Test short description
 	if cmd.Short == "" {
 		t.Error("Command should have a short description")
 	}
 }
+
+func TestListCmdFlagsWebhookFilter(t *testing.T) {
+	flags := listCmdFlags{
+		inactive:      true,
+		events:        []string{"push"},
+		urlMatch:      `example\.com`,
+		createdBefore: "2024-01-01",
+	}
+
+	filter, err := flags.webhookFilter()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if filter.Active == nil || *filter.Active {
+		t.Error("Expected filter to select inactive webhooks")
+	}
+	if filter.URLMatch == nil || !filter.URLMatch.MatchString("https://example.com/hook") {
+		t.Error("Expected URL expression to be compiled")
+	}
+	if filter.CreatedBefore.Year() != 2024 {
+		t.Errorf("Expected created before 2024, got %v", filter.CreatedBefore)
+	}
+
+	flags = listCmdFlags{urlMatch: "("}
+	if _, err := flags.webhookFilter(); err == nil {
+		t.Error("Expected error for invalid expression, got nil")
+	}
+
+	flags = listCmdFlags{updatedSince: "last week"}
+	if _, err := flags.webhookFilter(); err == nil {
+		t.Error("Expected error for invalid date, got nil")
+	}
+}
+
+func TestListCmdFlagsOutputFile(t *testing.T) {
+	newCommand := func(f *listCmdFlags) *cobra.Command {
+		cmd := &cobra.Command{Use: "list"}
+		cmd.Flags().StringVarP(&f.listFile, "output-file", "o", "WebhookReport-20230411160920.csv", "")
+		cmd.Flags().StringVarP(&f.format, "format", "", "csv", "")
+		cmd.Flags().StringVarP(&f.template, "template", "", "", "")
+		return cmd
+	}
+
+	f := listCmdFlags{}
+	cmd := newCommand(&f)
+	f.format = "json"
+	if got := f.outputFile(cmd); got != "WebhookReport-20230411160920.json" {
+		t.Errorf("Expected the default file to take the format's extension, got %q", got)
+	}
+	f.template = "{{range .}}{{.ID}}{{end}}"
+	if got := f.outputFile(cmd); got != "" {
+		t.Errorf("Expected template output to go to stdout, got %q", got)
+	}
+
+	f = listCmdFlags{}
+	cmd = newCommand(&f)
+	p := config.Profile{Name: "reports", Flags: map[string]map[string]string{"list": {
+		"output-file": "reports/webhooks.csv",
+		"format":      "json",
+		"template":    "{{range .}}{{.ID}}{{end}}",
+	}}}
+	if err := p.Apply(cmd); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got := f.outputFile(cmd); got != "reports/webhooks.csv" {
+		t.Errorf("Expected the profile's output file to be used as is, got %q", got)
+	}
+}
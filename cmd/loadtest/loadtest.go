@@ -0,0 +1,115 @@
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/client"
+	"github.com/katiem0/gh-organization-webhooks/internal/loadtest"
+	"github.com/katiem0/gh-organization-webhooks/internal/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type loadtestCmdFlags struct {
+	url           string
+	event         string
+	organization  string
+	secret        string
+	contentType   string
+	rate          float64
+	concurrency   int
+	requests      int
+	duration      time.Duration
+	targetTimeout time.Duration
+	timeout       time.Duration
+	maxErrorRate  float64
+	debug         bool
+}
+
+func NewCmdLoadtest() *cobra.Command {
+	loadtestCmdFlags := loadtestCmdFlags{}
+
+	loadtestCmd := &cobra.Command{
+		Use:   "loadtest [flags]",
+		Short: "Send signed synthetic webhook deliveries to an endpoint",
+		Long:  "Send signed synthetic payloads for an event type to an endpoint at a configured rate and concurrency, reporting latency percentiles and error rates",
+		Args:  cobra.NoArgs,
+		RunE: func(loadtestCmd *cobra.Command, args []string) error {
+			// Reinitialize logging if debugging was enabled
+			if loadtestCmdFlags.debug {
+				logger, _ := log.NewLogger(loadtestCmdFlags.debug)
+				defer logger.Sync() // nolint:errcheck
+				zap.ReplaceGlobals(logger)
+			}
+
+			if loadtestCmdFlags.secret == "" {
+				loadtestCmdFlags.secret = os.Getenv("WEBHOOK_SECRET")
+			}
+			if loadtestCmdFlags.contentType != "json" && loadtestCmdFlags.contentType != "form" {
+				return fmt.Errorf("invalid content type %q, expected json or form", loadtestCmdFlags.contentType)
+			}
+
+			ctx, cancel := client.CommandContext(loadtestCmdFlags.timeout)
+			defer cancel()
+
+			return runCmdLoadtest(ctx, &loadtestCmdFlags, &http.Client{Timeout: loadtestCmdFlags.targetTimeout}, os.Stdout)
+		},
+	}
+
+	// Configure flags for command
+	loadtestCmd.Flags().StringVarP(&loadtestCmdFlags.url, "url", "u", "", "URL of the endpoint to send deliveries to")
+	loadtestCmd.Flags().StringVarP(&loadtestCmdFlags.event, "event", "e", "push", fmt.Sprintf("Event type of the synthetic payloads: {%s}", strings.Join(loadtest.Events(), "|")))
+	loadtestCmd.Flags().StringVarP(&loadtestCmdFlags.organization, "organization", "", "my-org", "Organization login used in the synthetic payloads")
+	loadtestCmd.Flags().StringVarP(&loadtestCmdFlags.secret, "secret", "s", "", `Secret used to sign payloads (default "$WEBHOOK_SECRET")`)
+	loadtestCmd.Flags().StringVarP(&loadtestCmdFlags.contentType, "content-type", "", "json", "Content type of the deliveries: {json|form}")
+	loadtestCmd.Flags().Float64VarP(&loadtestCmdFlags.rate, "rate", "r", 10, "Deliveries to start per second")
+	loadtestCmd.Flags().IntVarP(&loadtestCmdFlags.concurrency, "concurrency", "c", 4, "Maximum deliveries in flight at once")
+	loadtestCmd.Flags().IntVarP(&loadtestCmdFlags.requests, "requests", "n", 0, "Number of deliveries to send (default unlimited until --duration)")
+	loadtestCmd.Flags().DurationVarP(&loadtestCmdFlags.duration, "duration", "", 30*time.Second, "How long to send deliveries for (0 for no limit with --requests)")
+	loadtestCmd.Flags().DurationVarP(&loadtestCmdFlags.targetTimeout, "target-timeout", "", 10*time.Second, "Maximum time for each delivery, matching GitHub's delivery timeout")
+	client.AddCommandTimeoutFlag(loadtestCmd.Flags(), &loadtestCmdFlags.timeout)
+	loadtestCmd.Flags().Float64VarP(&loadtestCmdFlags.maxErrorRate, "max-error-rate", "", 100, "Exit with an error when the percentage of failed deliveries exceeds this value")
+	loadtestCmd.PersistentFlags().BoolVarP(&loadtestCmdFlags.debug, "debug", "d", false, "To debug logging")
+
+	_ = loadtestCmd.MarkFlagRequired("url")
+
+	return loadtestCmd
+}
+
+func runCmdLoadtest(ctx context.Context, flags *loadtestCmdFlags, httpClient *http.Client, out io.Writer) error {
+	cfg := loadtest.Config{
+		URL:          flags.url,
+		Event:        flags.event,
+		Organization: flags.organization,
+		Secret:       []byte(flags.secret),
+		ContentType:  flags.contentType,
+		Rate:         flags.rate,
+		Concurrency:  flags.concurrency,
+		Requests:     flags.requests,
+		Duration:     flags.duration,
+		Client:       httpClient,
+	}
+	if len(cfg.Secret) == 0 {
+		zap.S().Warn("No secret configured, deliveries will be sent without a signature")
+	}
+
+	zap.S().Debugf("Sending %s deliveries to %s at %.1f req/s with concurrency %d", cfg.Event, cfg.URL, cfg.Rate, cfg.Concurrency)
+	result, err := loadtest.Run(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if err := result.Write(out, cfg); err != nil {
+		return err
+	}
+
+	if result.ErrorRate() > flags.maxErrorRate {
+		return fmt.Errorf("error rate %.2f%% exceeds the maximum of %.2f%%", result.ErrorRate(), flags.maxErrorRate)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewCmdLoadtest(t *testing.T) {
+	cmd := NewCmdLoadtest()
+
+	if cmd == nil {
+		t.Fatal("NewCmdLoadtest() returned nil")
+	}
+
+	if cmd.Use != "loadtest [flags]" {
+		t.Errorf("Expected Use to be 'loadtest [flags]', got %s", cmd.Use)
+	}
+
+	for _, name := range []string{"url", "event", "organization", "secret", "content-type", "rate", "concurrency", "requests", "duration", "target-timeout", "timeout", "max-error-rate", "debug"} {
+		if cmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+}
+
+func TestRunCmdLoadtest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	flags := &loadtestCmdFlags{
+		url: server.URL, event: "push", organization: "test-org", contentType: "form",
+		rate: 500, concurrency: 2, requests: 5, duration: 5 * time.Second, maxErrorRate: 100,
+	}
+
+	var buf bytes.Buffer
+	if err := runCmdLoadtest(context.Background(), flags, server.Client(), &buf); err != nil {
+		t.Fatalf("Expected failures not to error without a limit, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "Sent 5 push deliveries") {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+
+	flags.maxErrorRate = 10
+	if err := runCmdLoadtest(context.Background(), flags, server.Client(), &buf); err == nil {
+		t.Error("Expected error when the error rate exceeds --max-error-rate, got nil")
+	}
+}
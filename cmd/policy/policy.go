@@ -0,0 +1,151 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-organization-webhooks/internal/client"
+	"github.com/katiem0/gh-organization-webhooks/internal/config"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/lint"
+	"github.com/katiem0/gh-organization-webhooks/internal/log"
+	"github.com/katiem0/gh-organization-webhooks/internal/policy"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type checkCmdFlags struct {
+	token          string
+	hostname       string
+	appID          int64
+	appPrivateKey  string
+	installationID int64
+	policyFile     string
+	format         string
+	failLevel      string
+	timeout        time.Duration
+	requestTimeout time.Duration
+	debug          bool
+}
+
+type webhookGetter interface {
+	GetOrganizationWebhooks(ctx context.Context, owner string) ([]byte, error)
+}
+
+func NewCmdPolicy() *cobra.Command {
+	policyCmd := &cobra.Command{
+		Use:   "policy <command> [flags]",
+		Short: "Evaluate organization webhooks against a compliance policy",
+		Long:  "Evaluate organization webhooks against organization-specific compliance policy rules",
+	}
+
+	policyCmd.AddCommand(newCmdCheck())
+	return policyCmd
+}
+
+func newCmdCheck() *cobra.Command {
+	checkCmdFlags := checkCmdFlags{}
+
+	checkCmd := &cobra.Command{
+		Use:   "check [<organization>] [flags]",
+		Short: "Check organization webhooks against a policy file",
+		Long:  "Check organization webhooks against a policy file, exiting with an error when the policy is violated",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(checkCmd *cobra.Command, args []string) error {
+			var err error
+			var restClient *api.RESTClient
+
+			// Reinitialize logging if debugging was enabled
+			if checkCmdFlags.debug {
+				logger, _ := log.NewLogger(checkCmdFlags.debug)
+				defer logger.Sync() // nolint:errcheck
+				zap.ReplaceGlobals(logger)
+			}
+
+			if checkCmdFlags.format != "text" && checkCmdFlags.format != "json" {
+				return fmt.Errorf("unsupported format %q, expected one of: text, json", checkCmdFlags.format)
+			}
+
+			failLevel, err := lint.ParseLevel(checkCmdFlags.failLevel)
+			if err != nil {
+				return err
+			}
+
+			p, err := policy.Load(checkCmdFlags.policyFile)
+			if err != nil {
+				return err
+			}
+
+			restClient, err = client.NewRESTClient(client.Options{
+				Hostname:       checkCmdFlags.hostname,
+				Token:          checkCmdFlags.token,
+				AppID:          checkCmdFlags.appID,
+				AppPrivateKey:  checkCmdFlags.appPrivateKey,
+				InstallationID: checkCmdFlags.installationID,
+				RequestTimeout: checkCmdFlags.requestTimeout,
+			})
+			if err != nil {
+				zap.S().Errorf("Error arose retrieving rest client: %v", err)
+				return err
+			}
+
+			ctx, cancel := client.CommandContext(checkCmdFlags.timeout)
+			defer cancel()
+
+			owner, err := config.FromContext(checkCmd.Context()).OrganizationArg(args, 0)
+			if err != nil {
+				return err
+			}
+
+			return runCmdCheck(ctx, owner, p, data.NewAPIGetter(restClient), os.Stdout, checkCmdFlags.format, failLevel)
+		},
+	}
+
+	// Configure flags for command
+	checkCmd.PersistentFlags().StringVarP(&checkCmdFlags.token, "token", "t", "", `GitHub personal access token for reading the organization (default "gh auth token")`)
+	checkCmd.PersistentFlags().StringVarP(&checkCmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
+	checkCmd.PersistentFlags().Int64VarP(&checkCmdFlags.appID, "app-id", "", 0, "GitHub App ID used to authenticate instead of a token (Requires --app-private-key and --installation-id)")
+	checkCmd.PersistentFlags().StringVarP(&checkCmdFlags.appPrivateKey, "app-private-key", "", "", "Path to the GitHub App private key PEM file")
+	checkCmd.PersistentFlags().Int64VarP(&checkCmdFlags.installationID, "installation-id", "", 0, "GitHub App installation ID for the organization")
+	checkCmd.Flags().StringVarP(&checkCmdFlags.policyFile, "policy-file", "p", "", "Path and Name of the YAML policy file")
+	checkCmd.Flags().StringVarP(&checkCmdFlags.format, "format", "", "text", "Output format of the violations: {text|json}")
+	checkCmd.Flags().StringVarP(&checkCmdFlags.failLevel, "fail-level", "", "error", "Exit with an error when violations are at least this level: {error|warning|note|none}")
+	client.AddTimeoutFlags(checkCmd.PersistentFlags(), &checkCmdFlags.timeout, &checkCmdFlags.requestTimeout)
+	checkCmd.PersistentFlags().BoolVarP(&checkCmdFlags.debug, "debug", "d", false, "To debug logging")
+	_ = checkCmd.MarkFlagRequired("policy-file")
+
+	return checkCmd
+}
+
+func runCmdCheck(ctx context.Context, owner string, p *policy.Policy, g webhookGetter, w io.Writer, format string, failLevel lint.Level) error {
+	zap.S().Debugf("Gathering Webhooks for %s", owner)
+	orgWebhooks, err := g.GetOrganizationWebhooks(ctx, owner)
+	if err != nil {
+		zap.S().Errorf("Error authenticating and getting response from webhooks endpoint for %v", owner)
+		return err
+	}
+
+	var responseWebhooks []data.Webhook
+	if err := json.Unmarshal(orgWebhooks, &responseWebhooks); err != nil {
+		return err
+	}
+
+	zap.S().Debugf("Evaluating policy against %d webhook(s)", len(responseWebhooks))
+	violations, err := p.Check(owner, responseWebhooks)
+	if err != nil {
+		return err
+	}
+	if err := lint.Write(w, format, violations); err != nil {
+		return err
+	}
+
+	if lint.Exceeds(violations, failLevel) {
+		return fmt.Errorf("webhooks for %s violate the policy", owner)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/lint"
+	"github.com/katiem0/gh-organization-webhooks/internal/policy"
+)
+
+func TestNewCmdPolicy(t *testing.T) {
+	cmd := NewCmdPolicy()
+
+	if cmd == nil {
+		t.Fatal("NewCmdPolicy() returned nil")
+	}
+
+	if cmd.Use != "policy <command> [flags]" {
+		t.Errorf("Expected Use to be 'policy <command> [flags]', got %s", cmd.Use)
+	}
+
+	checkCmd, _, err := cmd.Find([]string{"check"})
+	if err != nil || checkCmd.Name() != "check" {
+		t.Fatalf("Missing 'check' subcommand: %v", err)
+	}
+
+	for _, name := range []string{"token", "hostname", "policy-file", "format", "fail-level"} {
+		if checkCmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+}
+
+func TestRunCmdCheck(t *testing.T) {
+	p, err := policy.Parse([]byte("required_content_type: json\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+
+	webhooks := []data.Webhook{
+		{ID: 1, Config: data.Config{ContentType: "json", Url: "https://example.com/hook"}},
+	}
+	mockGetter := data.NewMockAPIGetter()
+	mockGetter.OrganizationWebhooksData, _ = json.Marshal(webhooks)
+
+	var buf bytes.Buffer
+	if err := runCmdCheck(context.Background(), "test-org", p, mockGetter, &buf, "text", lint.LevelError); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "No findings.") {
+		t.Errorf("Unexpected output %s", buf.String())
+	}
+
+	webhooks[0].Config.ContentType = "form"
+	mockGetter.OrganizationWebhooksData, _ = json.Marshal(webhooks)
+	buf.Reset()
+	if err := runCmdCheck(context.Background(), "test-org", p, mockGetter, &buf, "text", lint.LevelError); err == nil {
+		t.Error("Expected policy violation error, got nil")
+	}
+	if !strings.Contains(buf.String(), "required-content-type") {
+		t.Errorf("Unexpected output %s", buf.String())
+	}
+}
@@ -0,0 +1,160 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-organization-webhooks/internal/client"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/log"
+	"github.com/katiem0/gh-organization-webhooks/internal/relay"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type replayCmdFlags struct {
+	token          string
+	hostname       string
+	appID          int64
+	appPrivateKey  string
+	installationID int64
+	hookID         int64
+	deliveryID     int64
+	file           string
+	url            string
+	secret         string
+	targetTimeout  time.Duration
+	timeout        time.Duration
+	requestTimeout time.Duration
+	debug          bool
+}
+
+type deliveryGetter interface {
+	GetHookDelivery(ctx context.Context, owner string, hookID, deliveryID int64) ([]byte, error)
+}
+
+func NewCmdReplay() *cobra.Command {
+	replayCmdFlags := replayCmdFlags{}
+
+	replayCmd := &cobra.Command{
+		Use:   "replay [<organization>] [flags]",
+		Short: "Replay a recorded webhook delivery to a URL",
+		Long:  "Replay a recorded organization webhook delivery, fetched from GitHub or read from an exported file, to a URL such as a local service, re-signing the payload with a local secret",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(replayCmd *cobra.Command, args []string) error {
+			var err error
+			var restClient *api.RESTClient
+			var delivery data.HookDelivery
+
+			// Reinitialize logging if debugging was enabled
+			if replayCmdFlags.debug {
+				logger, _ := log.NewLogger(replayCmdFlags.debug)
+				defer logger.Sync() // nolint:errcheck
+				zap.ReplaceGlobals(logger)
+			}
+
+			if replayCmdFlags.secret == "" {
+				replayCmdFlags.secret = os.Getenv("WEBHOOK_SECRET")
+			}
+
+			ctx, cancel := client.CommandContext(replayCmdFlags.timeout)
+			defer cancel()
+
+			if replayCmdFlags.file != "" {
+				if len(args) > 0 {
+					return errors.New("an organization cannot be combined with --file")
+				}
+				zap.S().Debugf("Reading delivery from %s", replayCmdFlags.file)
+				delivery, err = relay.LoadDelivery(replayCmdFlags.file)
+				if err != nil {
+					return err
+				}
+			} else {
+				if len(args) == 0 || replayCmdFlags.hookID == 0 || replayCmdFlags.deliveryID == 0 {
+					return errors.New("an organization, --hook-id and --delivery-id are required unless --file is set")
+				}
+				restClient, err = client.NewRESTClient(client.Options{
+					Hostname:       replayCmdFlags.hostname,
+					Token:          replayCmdFlags.token,
+					AppID:          replayCmdFlags.appID,
+					AppPrivateKey:  replayCmdFlags.appPrivateKey,
+					InstallationID: replayCmdFlags.installationID,
+					RequestTimeout: replayCmdFlags.requestTimeout,
+				})
+				if err != nil {
+					zap.S().Errorf("Error arose retrieving rest client: %v", err)
+					return err
+				}
+				delivery, err = fetchDelivery(ctx, args[0], replayCmdFlags.hookID, replayCmdFlags.deliveryID, data.NewAPIGetter(restClient))
+				if err != nil {
+					return err
+				}
+			}
+
+			httpClient := &http.Client{Timeout: replayCmdFlags.targetTimeout}
+			return runCmdReplay(ctx, httpClient, delivery, &replayCmdFlags, os.Stdout)
+		},
+	}
+
+	// Configure flags for command
+	replayCmd.PersistentFlags().StringVarP(&replayCmdFlags.token, "token", "t", "", `GitHub personal access token for reading the organization (default "gh auth token")`)
+	replayCmd.PersistentFlags().StringVarP(&replayCmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
+	replayCmd.PersistentFlags().Int64VarP(&replayCmdFlags.appID, "app-id", "", 0, "GitHub App ID used to authenticate instead of a token (Requires --app-private-key and --installation-id)")
+	replayCmd.PersistentFlags().StringVarP(&replayCmdFlags.appPrivateKey, "app-private-key", "", "", "Path to the GitHub App private key PEM file")
+	replayCmd.PersistentFlags().Int64VarP(&replayCmdFlags.installationID, "installation-id", "", 0, "GitHub App installation ID for the organization")
+	replayCmd.Flags().Int64VarP(&replayCmdFlags.hookID, "hook-id", "", 0, "ID of the organization webhook that made the delivery")
+	replayCmd.Flags().Int64VarP(&replayCmdFlags.deliveryID, "delivery-id", "", 0, "ID of the delivery to replay")
+	replayCmd.Flags().StringVarP(&replayCmdFlags.file, "file", "f", "", "Read the delivery from an exported JSON or gzipped JSON file instead of GitHub")
+	replayCmd.Flags().StringVarP(&replayCmdFlags.url, "url", "u", "", "URL to POST the delivery to (e.g. http://localhost:8080)")
+	replayCmd.Flags().StringVarP(&replayCmdFlags.secret, "secret", "s", "", `Secret used to re-sign the payload (default "$WEBHOOK_SECRET")`)
+	replayCmd.Flags().DurationVarP(&replayCmdFlags.targetTimeout, "target-timeout", "", 30*time.Second, "Maximum time for the request to the URL")
+	client.AddTimeoutFlags(replayCmd.PersistentFlags(), &replayCmdFlags.timeout, &replayCmdFlags.requestTimeout)
+	replayCmd.PersistentFlags().BoolVarP(&replayCmdFlags.debug, "debug", "d", false, "To debug logging")
+
+	replayCmd.MarkFlagsMutuallyExclusive("file", "hook-id")
+	replayCmd.MarkFlagsMutuallyExclusive("file", "delivery-id")
+	_ = replayCmd.MarkFlagRequired("url")
+
+	return replayCmd
+}
+
+func fetchDelivery(ctx context.Context, owner string, hookID, deliveryID int64, g deliveryGetter) (data.HookDelivery, error) {
+	var delivery data.HookDelivery
+	zap.S().Debugf("Gathering delivery %d of hook %d for %s", deliveryID, hookID, owner)
+	raw, err := g.GetHookDelivery(ctx, owner, hookID, deliveryID)
+	if err != nil {
+		zap.S().Errorf("Error getting delivery %d of hook %d for %s", deliveryID, hookID, owner)
+		return delivery, err
+	}
+	if err := json.Unmarshal(raw, &delivery); err != nil {
+		return delivery, err
+	}
+	return delivery, nil
+}
+
+func runCmdReplay(ctx context.Context, httpClient *http.Client, delivery data.HookDelivery, flags *replayCmdFlags, out io.Writer) error {
+	if flags.secret == "" {
+		zap.S().Warn("No secret configured, the delivery will be sent without a signature")
+	}
+
+	zap.S().Debugf("Replaying %s delivery %d to %s", delivery.Event, delivery.ID, flags.url)
+	result, err := relay.Send(ctx, httpClient, flags.url, delivery, []byte(flags.secret))
+	if err != nil {
+		return fmt.Errorf("unable to replay delivery %d: %w", delivery.ID, err)
+	}
+
+	fmt.Fprintf(out, "Replayed %s delivery %d (%s) to %s: %d %s in %s\n",
+		delivery.Event, delivery.ID, delivery.GUID, flags.url,
+		result.StatusCode, http.StatusText(result.StatusCode), result.Duration.Round(time.Millisecond))
+	if result.StatusCode < 200 || result.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with status %d", flags.url, result.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/pkg/signature"
+)
+
+const deliveryJSON = `{
+  "id": 42,
+  "guid": "0b989ba4-242f-11e5-81e1-c7b6966d2516",
+  "event": "push",
+  "request": {
+    "headers": {"Content-Type": "application/json", "X-GitHub-Event": "push"},
+    "payload": {"ref": "refs/heads/main"}
+  },
+  "response": {"headers": {}, "payload": ""}
+}`
+
+func TestNewCmdReplay(t *testing.T) {
+	cmd := NewCmdReplay()
+
+	if cmd == nil {
+		t.Fatal("NewCmdReplay() returned nil")
+	}
+
+	if cmd.Use != "replay [<organization>] [flags]" {
+		t.Errorf("Expected Use to be 'replay [<organization>] [flags]', got %s", cmd.Use)
+	}
+
+	for _, name := range []string{"token", "hostname", "hook-id", "delivery-id", "file", "url", "secret", "target-timeout", "timeout", "request-timeout", "debug"} {
+		if cmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+}
+
+func TestFetchDelivery(t *testing.T) {
+	mockGetter := data.NewMockAPIGetter()
+	mockGetter.HookDeliveryData = []byte(deliveryJSON)
+
+	delivery, err := fetchDelivery(context.Background(), "test-org", 1, 42, mockGetter)
+	if err != nil {
+		t.Fatalf("fetchDelivery() error = %v", err)
+	}
+	if delivery.ID != 42 || delivery.Event != "push" {
+		t.Errorf("Unexpected delivery %+v", delivery)
+	}
+
+	mockGetter.MethodErrors = map[string]error{"GetHookDelivery": errors.New("not found")}
+	if _, err := fetchDelivery(context.Background(), "test-org", 1, 42, mockGetter); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestRunCmdReplay(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := signature.VerifyHeaders([]byte("local"), body, r.Header); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	delivery, _ := fetchDelivery(context.Background(), "test-org", 1, 42, &data.MockAPIGetter{HookDeliveryData: []byte(deliveryJSON)})
+	flags := &replayCmdFlags{url: server.URL, secret: "local"}
+
+	var buf bytes.Buffer
+	if err := runCmdReplay(context.Background(), server.Client(), delivery, flags, &buf); err != nil {
+		t.Fatalf("runCmdReplay() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "Replayed push delivery 42") || !strings.Contains(buf.String(), "200 OK") {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+
+	status = http.StatusInternalServerError
+	if err := runCmdReplay(context.Background(), server.Client(), delivery, flags, &buf); err == nil {
+		t.Error("Expected error for failed replay, got nil")
+	}
+}
@@ -0,0 +1,344 @@
+package restore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-organization-webhooks/internal/client"
+	"github.com/katiem0/gh-organization-webhooks/internal/config"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/log"
+	"github.com/katiem0/gh-organization-webhooks/internal/snapshot"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+type restoreCmdFlags struct {
+	token          string
+	hostname       string
+	appID          int64
+	appPrivateKey  string
+	installationID int64
+	dryRun         bool
+	secretsFile    string
+	noPrompt       bool
+	timeout        time.Duration
+	requestTimeout time.Duration
+	debug          bool
+}
+
+// restoreHost returns the host given with --hostname on the command line, and
+// otherwise the host the snapshot was taken from. A profile's host that differs
+// from the snapshot's is an error rather than silently restoring elsewhere.
+func restoreHost(cmd *cobra.Command, hostname string, s *snapshot.Snapshot) (string, error) {
+	if cmd.Flags().Changed("hostname") {
+		return hostname, nil
+	}
+	if s.Hostname == "" {
+		if hostname == "" {
+			return "github.com", nil
+		}
+		return hostname, nil
+	}
+	if hostname != "" && !strings.EqualFold(hostname, s.Hostname) {
+		return "", fmt.Errorf("the profile's host %s is not the host %s the snapshot was taken from, use --hostname to choose the host to restore to", hostname, s.Hostname)
+	}
+	return s.Hostname, nil
+}
+
+type webhookRestorer interface {
+	GetOrganizationWebhooks(ctx context.Context, owner string) ([]byte, error)
+	CreateOrganizationWebhook(ctx context.Context, owner string, data io.Reader) ([]byte, error)
+	UpdateOrganizationWebhook(ctx context.Context, owner string, hookID int64, data io.Reader) ([]byte, error)
+	UpdateOrganizationWebhookConfig(ctx context.Context, owner string, hookID int64, data io.Reader) ([]byte, error)
+}
+
+func NewCmdRestore() *cobra.Command {
+	restoreCmdFlags := restoreCmdFlags{}
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore <snapshot> [<organization>] [flags]",
+		Short: "Restore organization webhooks from a snapshot",
+		Long:  "Recreate the webhooks of a snapshot that are missing from an organization and revert the ones that have changed. Webhooks that aren't in the snapshot are left alone.",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(restoreCmd *cobra.Command, args []string) error {
+			var err error
+			var restClient *api.RESTClient
+
+			// Reinitialize logging if debugging was enabled
+			if restoreCmdFlags.debug {
+				logger, _ := log.NewLogger(restoreCmdFlags.debug)
+				defer logger.Sync() // nolint:errcheck
+				zap.ReplaceGlobals(logger)
+			}
+
+			s, err := snapshot.Load(args[0])
+			if err != nil {
+				return err
+			}
+			secrets, err := loadSecrets(restoreCmdFlags.secretsFile)
+			if err != nil {
+				return err
+			}
+
+			hostname, err := restoreHost(restoreCmd, restoreCmdFlags.hostname, s)
+			if err != nil {
+				return err
+			}
+
+			restClient, err = client.NewRESTClient(client.Options{
+				Hostname:       hostname,
+				Token:          restoreCmdFlags.token,
+				AppID:          restoreCmdFlags.appID,
+				AppPrivateKey:  restoreCmdFlags.appPrivateKey,
+				InstallationID: restoreCmdFlags.installationID,
+				RequestTimeout: restoreCmdFlags.requestTimeout,
+				WritePacing:    client.WritePacing{Rate: client.DefaultWriteRate},
+			})
+			if err != nil {
+				zap.S().Errorf("Error arose retrieving rest client: %v", err)
+				return err
+			}
+
+			ctx, cancel := client.WithTimeout(context.Background(), restoreCmdFlags.timeout)
+			defer cancel()
+
+			owner, err := config.FromContext(restoreCmd.Context()).OrganizationArg(args, 1)
+			if err != nil {
+				return err
+			}
+			g := data.NewAPIGetter(restClient)
+
+			changes, err := planRestore(ctx, owner, s, g, os.Stdout)
+			if err != nil {
+				return err
+			}
+			if restoreCmdFlags.dryRun {
+				return runCmdRestore(ctx, owner, changes, g, true, os.Stdout)
+			}
+
+			// Secrets are resolved before anything is written, so a restore
+			// isn't left half done waiting on a prompt
+			if err := addProfileSecrets(changes, secrets, config.FromContext(restoreCmd.Context())); err != nil {
+				return err
+			}
+			var prompt func(string) string
+			if !restoreCmdFlags.noPrompt {
+				prompt = data.SensitivePrompt
+			}
+			if err := resolveSecrets(changes, secrets, prompt); err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+			defer stop()
+
+			return runCmdRestore(ctx, owner, changes, g, false, os.Stdout)
+		},
+	}
+
+	// Configure flags for command
+	restoreCmd.PersistentFlags().StringVarP(&restoreCmdFlags.token, "token", "t", "", `GitHub personal access token for the organization to restore (default "gh auth token")`)
+	restoreCmd.PersistentFlags().StringVarP(&restoreCmdFlags.hostname, "hostname", "", "", "GitHub Enterprise Server hostname (default the host the snapshot was taken from)")
+	restoreCmd.PersistentFlags().Int64VarP(&restoreCmdFlags.appID, "app-id", "", 0, "GitHub App ID used to authenticate instead of a token (Requires --app-private-key and --installation-id)")
+	restoreCmd.PersistentFlags().StringVarP(&restoreCmdFlags.appPrivateKey, "app-private-key", "", "", "Path to the GitHub App private key PEM file")
+	restoreCmd.PersistentFlags().Int64VarP(&restoreCmdFlags.installationID, "installation-id", "", 0, "GitHub App installation ID for the organization")
+	restoreCmd.Flags().BoolVarP(&restoreCmdFlags.dryRun, "dry-run", "n", false, "List the changes that would be made without making them")
+	restoreCmd.Flags().StringVarP(&restoreCmdFlags.secretsFile, "secrets-file", "", "", "YAML file mapping webhook URLs to the secrets to recreate them with, taking precedence over the profile's secrets")
+	restoreCmd.Flags().BoolVarP(&restoreCmdFlags.noPrompt, "no-prompt", "", false, "Fail instead of prompting for secrets missing from --secrets-file")
+	client.AddTimeoutFlags(restoreCmd.PersistentFlags(), &restoreCmdFlags.timeout, &restoreCmdFlags.requestTimeout)
+	restoreCmd.PersistentFlags().BoolVarP(&restoreCmdFlags.debug, "debug", "d", false, "To debug logging")
+
+	return restoreCmd
+}
+
+// loadSecrets reads a YAML map of webhook URL to secret. A value of the form
+// env:NAME is read from the environment variable NAME.
+func loadSecrets(path string) (map[string]string, error) {
+	secrets := map[string]string{}
+	if path == "" {
+		return secrets, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secrets file: %w", err)
+	}
+	if err := yaml.Unmarshal(b, &secrets); err != nil {
+		return nil, fmt.Errorf("invalid secrets file: %w", err)
+	}
+	for url, secret := range secrets {
+		if secrets[url], err = config.Resolve(secret); err != nil {
+			return nil, fmt.Errorf("secret for %s: %w", url, err)
+		}
+	}
+	return secrets, nil
+}
+
+// addProfileSecrets adds the secrets the profile configures for webhooks to
+// be recreated, unless the secrets file already has them
+func addProfileSecrets(changes []snapshot.Change, secrets map[string]string, profile config.Profile) error {
+	for _, change := range changes {
+		url := change.Webhook.Config.Url
+		if _, ok := secrets[url]; ok || change.Action != snapshot.ActionCreate {
+			continue
+		}
+		secret, ok, err := profile.Secret(url)
+		if err != nil {
+			return err
+		}
+		if ok {
+			secrets[url] = secret
+		}
+	}
+	return nil
+}
+
+// planRestore compares the snapshot with the organization's current webhooks
+// and reports the webhooks that are left alone
+func planRestore(ctx context.Context, owner string, s *snapshot.Snapshot, g webhookRestorer, out io.Writer) ([]snapshot.Change, error) {
+	zap.S().Debugf("Gathering webhooks for %s", owner)
+	orgWebhooks, err := g.GetOrganizationWebhooks(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	var current []data.Webhook
+	if err := json.Unmarshal(orgWebhooks, &current); err != nil {
+		return nil, fmt.Errorf("unable to parse webhooks for %s: %w", owner, err)
+	}
+
+	changes, extra := s.Plan(owner, current)
+	for _, webhook := range extra {
+		fmt.Fprintf(out, "Leaving webhook %d (%s) in %s, it is not in the snapshot\n", webhook.ID, webhook.Config.Url, owner)
+	}
+	return changes, nil
+}
+
+// resolveSecrets sets the secret of every webhook to be recreated whose secret
+// was redacted in the snapshot, from secrets or else by prompting. With a nil
+// prompt any secret missing from secrets is an error.
+func resolveSecrets(changes []snapshot.Change, secrets map[string]string, prompt func(string) string) error {
+	var missing []string
+	for i, change := range changes {
+		webhook := change.Webhook
+		if change.Action != snapshot.ActionCreate || webhook.Config.Secret != snapshot.RedactedSecret {
+			continue
+		}
+		if secret, ok := secrets[webhook.Config.Url]; ok {
+			changes[i].Webhook.Config.Secret = secret
+			continue
+		}
+		if prompt == nil {
+			missing = append(missing, webhook.Config.Url)
+			continue
+		}
+		zap.S().Debugf("Webhook with URL %s required a secret, and needs a new secret to be entered.", webhook.Config.Url)
+		changes[i].Webhook.Config.Secret = prompt(fmt.Sprintf("Please enter the secret to recreate webhook %s with:", webhook.Config.Url))
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("no secret given for %s, add them to --secrets-file", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// runCmdRestore applies the changes, continuing past webhooks that fail. When
+// dryRun is set the changes are only listed.
+func runCmdRestore(ctx context.Context, owner string, changes []snapshot.Change, g webhookRestorer, dryRun bool, out io.Writer) error {
+	if len(changes) == 0 {
+		fmt.Fprintf(out, "Webhooks in %s already match the snapshot.\n", owner)
+		return nil
+	}
+	if dryRun {
+		for _, change := range changes {
+			if change.Action == snapshot.ActionCreate {
+				fmt.Fprintf(out, "Would create webhook %s in %s\n", change.Webhook.Config.Url, owner)
+			} else {
+				fmt.Fprintf(out, "Would revert %s of webhook %d (%s) in %s\n", strings.Join(change.Differences, ", "), change.Current.ID, change.Current.Config.Url, owner)
+			}
+		}
+		return nil
+	}
+
+	var errs []error
+	restored := 0
+	for _, change := range changes {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stopped after restoring %d of %d webhooks: %w", restored, len(changes), err)
+		}
+		if err := applyChange(ctx, owner, change, g, out); err != nil {
+			zap.S().Errorf("Error arose restoring webhook %s: %v", change.Webhook.Config.Url, err)
+			fmt.Fprintf(out, "Failed to restore webhook %s in %s: %v\n", change.Webhook.Config.Url, owner, err)
+			errs = append(errs, err)
+			continue
+		}
+		restored++
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to restore %d of %d webhooks: %w", len(errs), len(changes), errors.Join(errs...))
+	}
+	fmt.Fprintf(out, "Restored %d webhooks in %s.\n", len(changes), owner)
+	return nil
+}
+
+func applyChange(ctx context.Context, owner string, change snapshot.Change, g webhookRestorer, out io.Writer) error {
+	webhook := change.Webhook
+	if change.Action == snapshot.ActionCreate {
+		payload, err := json.Marshal(data.CreatedWebhook{
+			Name:   webhook.Name,
+			Active: webhook.Active,
+			Events: webhook.Events,
+			Config: webhook.Config,
+		})
+		if err != nil {
+			return err
+		}
+		body, err := g.CreateOrganizationWebhook(ctx, owner, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		var created data.Webhook
+		_ = json.Unmarshal(body, &created)
+		fmt.Fprintf(out, "Created webhook %d (%s) in %s\n", created.ID, webhook.Config.Url, owner)
+		return nil
+	}
+
+	hookID := int64(change.Current.ID)
+	if change.UpdatesHook() {
+		payload, err := json.Marshal(map[string]any{
+			"active": webhook.Active,
+			"events": webhook.Events,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := g.UpdateOrganizationWebhook(ctx, owner, hookID, bytes.NewReader(payload)); err != nil {
+			return err
+		}
+	}
+	if change.UpdatesConfig() {
+		// The secret is left out so the webhook keeps its current one
+		payload, err := json.Marshal(map[string]string{
+			"url":          webhook.Config.Url,
+			"content_type": webhook.Config.ContentType,
+			"insecure_ssl": webhook.Config.InsecureSSL,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := g.UpdateOrganizationWebhookConfig(ctx, owner, hookID, bytes.NewReader(payload)); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(out, "Reverted %s of webhook %d (%s) in %s\n", strings.Join(change.Differences, ", "), hookID, webhook.Config.Url, owner)
+	return nil
+}
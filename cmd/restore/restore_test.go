@@ -0,0 +1,237 @@
+package restore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/config"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/snapshot"
+)
+
+func TestNewCmdRestore(t *testing.T) {
+	cmd := NewCmdRestore()
+
+	if cmd == nil {
+		t.Fatal("NewCmdRestore() returned nil")
+	}
+
+	if cmd.Use != "restore <snapshot> [<organization>] [flags]" {
+		t.Errorf("Expected Use to be 'restore <snapshot> [<organization>] [flags]', got %s", cmd.Use)
+	}
+
+	for _, name := range []string{"token", "hostname", "app-id", "app-private-key", "installation-id", "dry-run", "secrets-file", "no-prompt", "timeout", "request-timeout", "debug"} {
+		if cmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+}
+
+func hook(id int, url string, events ...string) data.Webhook {
+	return data.Webhook{
+		HookType: "Organization",
+		ID:       id,
+		Name:     "web",
+		Active:   true,
+		Events:   events,
+		Config:   data.Config{ContentType: "json", InsecureSSL: "0", Secret: snapshot.RedactedSecret, Url: url},
+	}
+}
+
+func testSnapshot() *snapshot.Snapshot {
+	return snapshot.New("github.com", "my-org", "v1.2.3", []data.Webhook{
+		hook(1, "https://ci.example.com/github", "push"),
+		hook(2, "https://deploy.example.com/github", "deployment"),
+	})
+}
+
+func TestRestore(t *testing.T) {
+	g := data.NewMockAPIGetter()
+	g.OrganizationWebhooksData = []byte(`[
+		{"id":2,"name":"web","active":false,"events":["deployment"],
+		 "config":{"content_type":"form","insecure_ssl":"0","secret":"********","url":"https://deploy.example.com/github"}},
+		{"id":9,"name":"web","active":true,"events":["push"],
+		 "config":{"content_type":"json","insecure_ssl":"0","url":"https://other.example.com/github"}}
+	]`)
+	g.CreatedWebhookData = []byte(`{"id":10}`)
+
+	var out bytes.Buffer
+	changes, err := planRestore(context.Background(), "my-org", testSnapshot(), g, &out)
+	if err != nil {
+		t.Fatalf("planRestore() error = %v", err)
+	}
+	if err := resolveSecrets(changes, map[string]string{"https://ci.example.com/github": "ci-secret"}, nil); err != nil {
+		t.Fatalf("resolveSecrets() error = %v", err)
+	}
+	if err := runCmdRestore(context.Background(), "my-org", changes, g, false, &out); err != nil {
+		t.Fatalf("runCmdRestore() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"Leaving webhook 9 (https://other.example.com/github) in my-org",
+		"Created webhook 10 (https://ci.example.com/github) in my-org",
+		"Reverted active, config.content_type of webhook 2",
+		"Restored 2 webhooks in my-org.",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected output to contain %q, got %s", want, out.String())
+		}
+	}
+	if string(g.UpdatedWebhooks[2]) != `{"active":true,"events":["deployment"]}` {
+		t.Errorf("Unexpected webhook update %s", g.UpdatedWebhooks[2])
+	}
+	if config := string(g.UpdatedWebhookConfigs[2]); !strings.Contains(config, `"content_type":"json"`) || strings.Contains(config, "secret") {
+		t.Errorf("Unexpected config update %s", config)
+	}
+	if changes[0].Webhook.Config.Secret != "ci-secret" {
+		t.Errorf("Expected secret from the secrets file, got %q", changes[0].Webhook.Config.Secret)
+	}
+}
+
+func TestRestoreDryRun(t *testing.T) {
+	g := data.NewMockAPIGetter()
+	g.OrganizationWebhooksData = []byte(`[]`)
+
+	var out bytes.Buffer
+	changes, err := planRestore(context.Background(), "my-org", testSnapshot(), g, &out)
+	if err != nil {
+		t.Fatalf("planRestore() error = %v", err)
+	}
+	if err := runCmdRestore(context.Background(), "my-org", changes, g, true, &out); err != nil {
+		t.Fatalf("runCmdRestore() error = %v", err)
+	}
+	if strings.Count(out.String(), "Would create webhook") != 2 {
+		t.Errorf("Expected both webhooks to be listed, got %s", out.String())
+	}
+	if len(g.UpdatedWebhooks) != 0 {
+		t.Errorf("Expected no writes in a dry run, got %v", g.UpdatedWebhooks)
+	}
+}
+
+func TestRestoreContinuesPastFailures(t *testing.T) {
+	g := data.NewMockAPIGetter()
+	g.MethodErrors = map[string]error{"CreateOrganizationWebhook": data.ErrValidationFailed}
+
+	changes := testSnapshot().Webhooks
+	var out bytes.Buffer
+	err := runCmdRestore(context.Background(), "my-org", []snapshot.Change{
+		{Action: snapshot.ActionCreate, Webhook: changes[0]},
+		{Action: snapshot.ActionCreate, Webhook: changes[1]},
+	}, g, false, &out)
+
+	if !errors.Is(err, data.ErrValidationFailed) || !strings.Contains(err.Error(), "failed to restore 2 of 2 webhooks") {
+		t.Errorf("Expected both failures to be reported, got %v", err)
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	changes := []snapshot.Change{
+		{Action: snapshot.ActionCreate, Webhook: hook(1, "https://ci.example.com/github", "push")},
+		{Action: snapshot.ActionCreate, Webhook: hook(2, "https://deploy.example.com/github", "deployment")},
+	}
+
+	if err := resolveSecrets(changes, nil, nil); err == nil || !strings.Contains(err.Error(), "https://deploy.example.com/github") {
+		t.Errorf("Expected missing secrets to be reported, got %v", err)
+	}
+
+	var prompted []string
+	prompt := func(label string) string {
+		prompted = append(prompted, label)
+		return "typed-secret"
+	}
+	if err := resolveSecrets(changes, map[string]string{"https://ci.example.com/github": "from-file"}, prompt); err != nil {
+		t.Fatalf("resolveSecrets() error = %v", err)
+	}
+	if changes[0].Webhook.Config.Secret != "from-file" || changes[1].Webhook.Config.Secret != "typed-secret" || len(prompted) != 1 {
+		t.Errorf("Unexpected secrets %+v after %d prompts", changes, len(prompted))
+	}
+}
+
+func TestLoadSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.yml")
+	content := "https://ci.example.com/github: plain\nhttps://deploy.example.com/github: env:DEPLOY_SECRET\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadSecrets(path); err == nil {
+		t.Error("Expected error for unset environment variable, got nil")
+	}
+
+	t.Setenv("DEPLOY_SECRET", "from-env")
+	secrets, err := loadSecrets(path)
+	if err != nil {
+		t.Fatalf("loadSecrets() error = %v", err)
+	}
+	if secrets["https://ci.example.com/github"] != "plain" || secrets["https://deploy.example.com/github"] != "from-env" {
+		t.Errorf("Unexpected secrets %v", secrets)
+	}
+}
+
+func TestAddProfileSecrets(t *testing.T) {
+	changes := []snapshot.Change{
+		{Action: snapshot.ActionCreate, Webhook: hook(1, "https://ci.example.com/github", "push")},
+		{Action: snapshot.ActionCreate, Webhook: hook(2, "https://deploy.example.com/github", "deployment")},
+	}
+	profile := config.Profile{Secrets: map[string]string{
+		"https://ci.example.com/github":     "from-profile",
+		"https://deploy.example.com/github": "from-profile",
+	}}
+	secrets := map[string]string{"https://deploy.example.com/github": "from-file"}
+
+	if err := addProfileSecrets(changes, secrets, profile); err != nil {
+		t.Fatalf("addProfileSecrets() error = %v", err)
+	}
+	if secrets["https://ci.example.com/github"] != "from-profile" || secrets["https://deploy.example.com/github"] != "from-file" {
+		t.Errorf("Expected the secrets file to take precedence over the profile, got %v", secrets)
+	}
+}
+
+func TestRestoreHost(t *testing.T) {
+	s := &snapshot.Snapshot{Hostname: "github.example.com"}
+
+	cmd := NewCmdRestore()
+	if got, err := restoreHost(cmd, "", s); err != nil || got != "github.example.com" {
+		t.Errorf("Expected the snapshot's host, got %q (%v)", got, err)
+	}
+	if got, err := restoreHost(cmd, "", &snapshot.Snapshot{}); err != nil || got != "github.com" {
+		t.Errorf("Expected github.com for a snapshot without a host, got %q (%v)", got, err)
+	}
+
+	// A profile's host can't silently override the snapshot's
+	p := config.Profile{Name: "other", Hostname: "other.example.com"}
+	if err := cmd.ParseFlags(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Apply(cmd); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if _, err := restoreHost(cmd, cmd.Flag("hostname").Value.String(), s); err == nil || !strings.Contains(err.Error(), "--hostname") {
+		t.Errorf("Expected the conflicting hosts to be reported, got %v", err)
+	}
+	p.Hostname = "GitHub.example.com"
+	cmd = NewCmdRestore()
+	if err := cmd.ParseFlags(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Apply(cmd); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got, err := restoreHost(cmd, cmd.Flag("hostname").Value.String(), s); err != nil || got != "github.example.com" {
+		t.Errorf("Expected a matching profile host to be accepted, got %q (%v)", got, err)
+	}
+
+	// --hostname on the command line chooses the host
+	cmd = NewCmdRestore()
+	if err := cmd.ParseFlags([]string{"--hostname", "other.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := restoreHost(cmd, "other.example.com", s); err != nil || got != "other.example.com" {
+		t.Errorf("Expected the command line host, got %q (%v)", got, err)
+	}
+}
@@ -0,0 +1,190 @@
+package rollback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-organization-webhooks/internal/client"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/journal"
+	"github.com/katiem0/gh-organization-webhooks/internal/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type rollbackCmdFlags struct {
+	token          string
+	appID          int64
+	appPrivateKey  string
+	installationID int64
+	dryRun         bool
+	timeout        time.Duration
+	requestTimeout time.Duration
+	debug          bool
+}
+
+type webhookDeleter interface {
+	DeleteOrganizationWebhook(ctx context.Context, owner string, hookID int64) error
+}
+
+func NewCmdRollback() *cobra.Command {
+	rollbackCmdFlags := rollbackCmdFlags{}
+
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback <journal> [flags]",
+		Short: "Delete the webhooks recorded in a create journal",
+		Long:  "Delete exactly the webhooks that a create run recorded in its journal, newest first, on the host the journal recorded",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(rollbackCmd *cobra.Command, args []string) error {
+			var err error
+			var restClient *api.RESTClient
+
+			// Reinitialize logging if debugging was enabled
+			if rollbackCmdFlags.debug {
+				logger, _ := log.NewLogger(rollbackCmdFlags.debug)
+				defer logger.Sync() // nolint:errcheck
+				zap.ReplaceGlobals(logger)
+			}
+
+			journalFile := args[0]
+			entries, err := journal.Read(journalFile)
+			if err != nil {
+				return err
+			}
+			outstanding := journal.Outstanding(entries)
+			if len(outstanding) == 0 {
+				fmt.Printf("Nothing to roll back in %s.\n", journalFile)
+				return nil
+			}
+			hostname, err := journalHost(outstanding)
+			if err != nil {
+				return fmt.Errorf("%s: %w", journalFile, err)
+			}
+
+			restClient, err = client.NewRESTClient(client.Options{
+				Hostname:       hostname,
+				Token:          rollbackCmdFlags.token,
+				AppID:          rollbackCmdFlags.appID,
+				AppPrivateKey:  rollbackCmdFlags.appPrivateKey,
+				InstallationID: rollbackCmdFlags.installationID,
+				RequestTimeout: rollbackCmdFlags.requestTimeout,
+				WritePacing:    client.WritePacing{Rate: client.DefaultWriteRate},
+			})
+			if err != nil {
+				zap.S().Errorf("Error arose retrieving rest client: %v", err)
+				return err
+			}
+
+			ctx, cancel := client.CommandContext(rollbackCmdFlags.timeout)
+			defer cancel()
+
+			var j *journal.Journal
+			if !rollbackCmdFlags.dryRun {
+				j, err = journal.Open(journalFile)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					if err := j.Close(); err != nil {
+						zap.S().Errorf("Error closing journal: %v", err)
+					}
+				}()
+			}
+
+			return runCmdRollback(ctx, outstanding, data.NewAPIGetter(restClient), j, os.Stdout)
+		},
+	}
+
+	// Configure flags for command
+	rollbackCmd.PersistentFlags().StringVarP(&rollbackCmdFlags.token, "token", "t", "", `GitHub personal access token for the organization the webhooks were created in (default "gh auth token")`)
+	rollbackCmd.PersistentFlags().Int64VarP(&rollbackCmdFlags.appID, "app-id", "", 0, "GitHub App ID used to authenticate instead of a token (Requires --app-private-key and --installation-id)")
+	rollbackCmd.PersistentFlags().StringVarP(&rollbackCmdFlags.appPrivateKey, "app-private-key", "", "", "Path to the GitHub App private key PEM file")
+	rollbackCmd.PersistentFlags().Int64VarP(&rollbackCmdFlags.installationID, "installation-id", "", 0, "GitHub App installation ID for the organization")
+	rollbackCmd.Flags().BoolVarP(&rollbackCmdFlags.dryRun, "dry-run", "n", false, "List the webhooks that would be deleted without deleting them")
+	client.AddTimeoutFlags(rollbackCmd.PersistentFlags(), &rollbackCmdFlags.timeout, &rollbackCmdFlags.requestTimeout)
+	rollbackCmd.PersistentFlags().BoolVarP(&rollbackCmdFlags.debug, "debug", "d", false, "To debug logging")
+
+	return rollbackCmd
+}
+
+// journalHost returns the host the entries were written to. Rolling back
+// writes to several hosts at once isn't supported, as credentials differ.
+func journalHost(entries []journal.Entry) (string, error) {
+	hosts := map[string]bool{}
+	for _, e := range entries {
+		hosts[e.Hostname] = true
+	}
+	if len(hosts) > 1 {
+		var names []string
+		for host := range hosts {
+			names = append(names, host)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("journal records webhooks on more than one host (%s)", strings.Join(names, ", "))
+	}
+	return entries[0].Hostname, nil
+}
+
+// runCmdRollback deletes the outstanding webhooks, recording each deletion in
+// the journal so that an interrupted rollback can be run again. With a nil
+// journal the webhooks are only listed.
+func runCmdRollback(ctx context.Context, outstanding []journal.Entry, g webhookDeleter, j *journal.Journal, out io.Writer) error {
+	if j == nil {
+		for _, e := range outstanding {
+			fmt.Fprintf(out, "Would delete webhook %d (%s) from %s\n", e.HookID, e.URL, e.Organization)
+		}
+		return nil
+	}
+
+	var errs []error
+	deleted := 0
+	for _, e := range outstanding {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stopped after deleting %d of %d webhooks: %w", deleted, len(outstanding), err)
+		}
+		if e.HookID == 0 {
+			fmt.Fprintf(out, "Skipped webhook %s in %s, its ID was not recorded, delete it by hand\n", e.URL, e.Organization)
+			errs = append(errs, fmt.Errorf("webhook %s in %s has no recorded ID", e.URL, e.Organization))
+			continue
+		}
+
+		zap.S().Debugf("Deleting webhook %d from %s", e.HookID, e.Organization)
+		err := g.DeleteOrganizationWebhook(ctx, e.Organization, e.HookID)
+		// A 404 may also mean the token can't see the webhook, so it is left
+		// outstanding rather than assumed to be gone
+		if errors.Is(err, data.ErrNotFound) {
+			fmt.Fprintf(out, "Webhook %d (%s) was not found in %s, check that it is gone or that the token can administer the organization's webhooks\n", e.HookID, e.URL, e.Organization)
+		}
+		if err != nil {
+			zap.S().Errorf("Error arose deleting webhook %d from %s: %v", e.HookID, e.Organization, err)
+			errs = append(errs, err)
+			continue
+		}
+		fmt.Fprintf(out, "Deleted webhook %d (%s) from %s\n", e.HookID, e.URL, e.Organization)
+		deleted++
+
+		err = j.Record(journal.Entry{
+			Action:       journal.ActionDelete,
+			Hostname:     e.Hostname,
+			Organization: e.Organization,
+			HookID:       e.HookID,
+			URL:          e.URL,
+		})
+		if err != nil {
+			return fmt.Errorf("recording deletion of webhook %d in journal %s: %w", e.HookID, j.Path, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to delete %d of %d webhooks: %w", len(errs), len(outstanding), errors.Join(errs...))
+	}
+	fmt.Fprintf(out, "Rolled back %d webhooks.\n", deleted)
+	return nil
+}
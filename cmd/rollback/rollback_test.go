@@ -0,0 +1,169 @@
+package rollback
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/journal"
+)
+
+func TestNewCmdRollback(t *testing.T) {
+	cmd := NewCmdRollback()
+
+	if cmd == nil {
+		t.Fatal("NewCmdRollback() returned nil")
+	}
+
+	if cmd.Use != "rollback <journal> [flags]" {
+		t.Errorf("Expected Use to be 'rollback <journal> [flags]', got %s", cmd.Use)
+	}
+
+	for _, name := range []string{"token", "app-id", "app-private-key", "installation-id", "dry-run", "timeout", "request-timeout", "debug"} {
+		if cmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+}
+
+// deleterMock fails to delete the hooks in errs
+type deleterMock struct {
+	data.MockAPIGetter
+	errs map[int64]error
+}
+
+func (m *deleterMock) DeleteOrganizationWebhook(ctx context.Context, owner string, hookID int64) error {
+	if err := m.errs[hookID]; err != nil {
+		return err
+	}
+	return m.MockAPIGetter.DeleteOrganizationWebhook(ctx, owner, hookID)
+}
+
+func writeJournal(t *testing.T, entries ...journal.Entry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, err := journal.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+	for _, e := range entries {
+		if err := j.Record(e); err != nil {
+			t.Fatalf("Failed to record entry: %v", err)
+		}
+	}
+	_ = j.Close()
+	return path
+}
+
+func created(id int64) journal.Entry {
+	return journal.Entry{Action: journal.ActionCreate, Hostname: "github.com", Organization: "target-org", HookID: id, URL: "https://hooks.example.com/github"}
+}
+
+func rollback(t *testing.T, path string, g webhookDeleter) (string, error) {
+	t.Helper()
+	entries, err := journal.Read(path)
+	if err != nil {
+		t.Fatalf("Failed to read journal: %v", err)
+	}
+	j, err := journal.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+	defer j.Close() // nolint:errcheck
+
+	var out bytes.Buffer
+	err = runCmdRollback(context.Background(), journal.Outstanding(entries), g, j, &out)
+	return out.String(), err
+}
+
+func TestRunCmdRollback(t *testing.T) {
+	path := writeJournal(t, created(1), created(2), created(3))
+	g := &deleterMock{}
+
+	out, err := rollback(t, path, g)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(g.DeletedHookIDs) != 3 || g.DeletedHookIDs[0] != 3 || g.DeletedHookIDs[2] != 1 {
+		t.Errorf("Expected hooks to be deleted newest first, got %v", g.DeletedHookIDs)
+	}
+	if !strings.Contains(out, "Rolled back 3 webhooks.") {
+		t.Errorf("Unexpected output %q", out)
+	}
+
+	// Running it again finds nothing left to delete
+	entries, _ := journal.Read(path)
+	if outstanding := journal.Outstanding(entries); len(outstanding) != 0 {
+		t.Errorf("Expected every webhook to be rolled back, got %+v", outstanding)
+	}
+}
+
+func TestRunCmdRollbackNotFound(t *testing.T) {
+	path := writeJournal(t, created(1), created(2))
+	g := &deleterMock{errs: map[int64]error{2: &data.APIError{Kind: data.ErrNotFound, StatusCode: 404}}}
+
+	out, err := rollback(t, path, g)
+	if err == nil || !errors.Is(err, data.ErrNotFound) {
+		t.Fatalf("Expected the missing webhook to fail the rollback, got %v", err)
+	}
+	if !strings.Contains(out, "Webhook 2 (https://hooks.example.com/github) was not found in target-org") {
+		t.Errorf("Unexpected output %q", out)
+	}
+
+	// The missing webhook isn't recorded as deleted
+	entries, _ := journal.Read(path)
+	outstanding := journal.Outstanding(entries)
+	if len(outstanding) != 1 || outstanding[0].HookID != 2 {
+		t.Errorf("Expected webhook 2 to be outstanding, got %+v", outstanding)
+	}
+}
+
+func TestRunCmdRollbackFailures(t *testing.T) {
+	path := writeJournal(t, created(1), created(2))
+	g := &deleterMock{errs: map[int64]error{2: &data.APIError{Kind: data.ErrForbidden, StatusCode: 403}}}
+
+	_, err := rollback(t, path, g)
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to delete 1 of 2 webhooks") {
+		t.Fatalf("Expected failure summary, got %v", err)
+	}
+	if !errors.Is(err, data.ErrForbidden) {
+		t.Errorf("Expected failure to wrap the API error, got %v", err)
+	}
+
+	// Only the failed webhook is left to roll back
+	entries, _ := journal.Read(path)
+	outstanding := journal.Outstanding(entries)
+	if len(outstanding) != 1 || outstanding[0].HookID != 2 {
+		t.Errorf("Expected webhook 2 to be outstanding, got %+v", outstanding)
+	}
+}
+
+func TestRunCmdRollbackDryRun(t *testing.T) {
+	g := &deleterMock{}
+	var out bytes.Buffer
+	if err := runCmdRollback(context.Background(), []journal.Entry{created(7)}, g, nil, &out); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(g.DeletedHookIDs) != 0 {
+		t.Errorf("Expected nothing deleted, got %v", g.DeletedHookIDs)
+	}
+	if out.String() != "Would delete webhook 7 (https://hooks.example.com/github) from target-org\n" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
+
+func TestJournalHost(t *testing.T) {
+	ghes := created(2)
+	ghes.Hostname = "ghes.example.com"
+
+	if host, err := journalHost([]journal.Entry{created(1)}); err != nil || host != "github.com" {
+		t.Errorf("Expected github.com, got %q (%v)", host, err)
+	}
+	if _, err := journalHost([]journal.Entry{created(1), ghes}); err == nil {
+		t.Error("Expected error for several hosts, got nil")
+	}
+}
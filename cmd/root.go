@@ -1,22 +1,106 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/spf13/cobra"
 
+	"github.com/katiem0/gh-organization-webhooks/internal/config"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+
+	archiveDeliveriesCmd "github.com/katiem0/gh-organization-webhooks/cmd/archivedeliveries"
 	createCmd "github.com/katiem0/gh-organization-webhooks/cmd/create"
+	doctorCmd "github.com/katiem0/gh-organization-webhooks/cmd/doctor"
+	forwardCmd "github.com/katiem0/gh-organization-webhooks/cmd/forward"
+	lintCmd "github.com/katiem0/gh-organization-webhooks/cmd/lint"
 	listCmd "github.com/katiem0/gh-organization-webhooks/cmd/list"
+	loadtestCmd "github.com/katiem0/gh-organization-webhooks/cmd/loadtest"
+	policyCmd "github.com/katiem0/gh-organization-webhooks/cmd/policy"
+	replayCmd "github.com/katiem0/gh-organization-webhooks/cmd/replay"
+	restoreCmd "github.com/katiem0/gh-organization-webhooks/cmd/restore"
+	rollbackCmd "github.com/katiem0/gh-organization-webhooks/cmd/rollback"
+	receiverCmd "github.com/katiem0/gh-organization-webhooks/cmd/servereceiver"
+	snapshotCmd "github.com/katiem0/gh-organization-webhooks/cmd/snapshot"
 )
 
+// Exit codes for failures that scripts may want to handle differently
+const (
+	ExitError            = 1
+	ExitNotFound         = 3
+	ExitForbidden        = 4
+	ExitRateLimited      = 5
+	ExitValidationFailed = 6
+)
+
+// ExitCode returns the exit code for an error returned by a command
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, data.ErrNotFound):
+		return ExitNotFound
+	case errors.Is(err, data.ErrForbidden):
+		return ExitForbidden
+	case errors.Is(err, data.ErrRateLimited):
+		return ExitRateLimited
+	case errors.Is(err, data.ErrValidationFailed):
+		return ExitValidationFailed
+	default:
+		return ExitError
+	}
+}
+
 func NewCmd() *cobra.Command {
+	var profileName string
+
+	// A broken config file only fails the commands that run, not --help
+	cfg, cfgErr := config.LoadDefault()
 
 	cmd := &cobra.Command{
 		Use:   "organization-webhooks <command> [flags]",
 		Short: "List and create organization webhooks.",
 		Long:  "List and create organization level webhooks.",
+		// Usage is only printed for invalid arguments, not when a command fails
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			if cfgErr != nil {
+				return cfgErr
+			}
+			// Flags given on the command line take precedence over the profile
+			profile, err := cfg.Profile(profileName)
+			if err != nil {
+				return err
+			}
+			if err := profile.Apply(cmd); err != nil {
+				return err
+			}
+			// Settings from the working directory are easy to miss, so say where they came from
+			if profile.Name != "" && slices.Contains(cfg.Files, config.DirectoryFile) {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Using profile %s from %s\n", profile.Name, strings.Join(cfg.Files, ", "))
+			}
+			cmd.SetContext(config.NewContext(cmd.Context(), profile))
+			return nil
+		},
 	}
 
+	cmd.PersistentFlags().StringVarP(&profileName, "profile", "", "", "Name of the config profile to use (default the default_profile of the config)")
+
 	cmd.AddCommand(listCmd.NewCmdList())
 	cmd.AddCommand(createCmd.NewCmdCreate())
+	cmd.AddCommand(rollbackCmd.NewCmdRollback())
+	cmd.AddCommand(snapshotCmd.NewCmdSnapshot())
+	cmd.AddCommand(restoreCmd.NewCmdRestore())
+	cmd.AddCommand(doctorCmd.NewCmdDoctor())
+	cmd.AddCommand(lintCmd.NewCmdLint())
+	cmd.AddCommand(policyCmd.NewCmdPolicy())
+	cmd.AddCommand(receiverCmd.NewCmdServeReceiver())
+	cmd.AddCommand(replayCmd.NewCmdReplay())
+	cmd.AddCommand(forwardCmd.NewCmdForward())
+	cmd.AddCommand(archiveDeliveriesCmd.NewCmdArchiveDeliveries())
+	cmd.AddCommand(loadtestCmd.NewCmdLoadtest())
 	cmd.CompletionOptions.DisableDefaultCmd = true
 	cmd.SetHelpCommand(&cobra.Command{
 		Use:    "no-help",
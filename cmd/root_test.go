@@ -1,7 +1,19 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/config"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
 )
 
 func TestNewCmd(t *testing.T) {
@@ -17,22 +29,15 @@ func TestNewCmd(t *testing.T) {
 	}
 
 	// Test subcommands
-	var hasListCmd, hasCreateCmd bool
+	subCommands := map[string]bool{}
 	for _, subCmd := range cmd.Commands() {
-		if subCmd.Name() == "list" {
-			hasListCmd = true
-		}
-		if subCmd.Name() == "create" {
-			hasCreateCmd = true
-		}
+		subCommands[subCmd.Name()] = true
 	}
 
-	if !hasListCmd {
-		t.Error("Missing 'list' subcommand")
-	}
-
-	if !hasCreateCmd {
-		t.Error("Missing 'create' subcommand")
+	for _, name := range []string{"list", "create", "rollback", "snapshot", "restore", "doctor", "lint", "policy", "serve-receiver", "replay", "forward", "archive-deliveries", "loadtest"} {
+		if !subCommands[name] {
+			t.Errorf("Missing '%s' subcommand", name)
+		}
 	}
 
 	// Test short description
@@ -50,3 +55,100 @@ func TestNewCmd(t *testing.T) {
 		t.Error("Default completion command should be disabled")
 	}
 }
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{errors.New("boom"), ExitError},
+		{&data.APIError{Kind: data.ErrNotFound}, ExitNotFound},
+		{&data.APIError{Kind: data.ErrForbidden}, ExitForbidden},
+		{&data.APIError{Kind: data.ErrRateLimited}, ExitRateLimited},
+		{fmt.Errorf("creating webhook: %w", &data.APIError{Kind: data.ErrValidationFailed}), ExitValidationFailed},
+		{&data.APIError{StatusCode: 500}, ExitError},
+	}
+
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestNewCmdProfile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	userFile := filepath.Join(dir, "config", "gh-organization-webhooks", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(userFile), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(userFile, []byte("profiles:\n  ghes:\n    hostname: github.example.com\n    organization: my-org\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	content := "profiles:\n  ghes:\n    flags:\n      probe:\n        format: json\n"
+	if err := os.WriteFile(config.DirectoryFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stderr bytes.Buffer
+	var format string
+	run := func(args ...string) (string, string, error) {
+		var hostname, owner string
+		stderr.Reset()
+		root := NewCmd()
+		probe := &cobra.Command{
+			Use: "probe",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				hostname, _ = cmd.Flags().GetString("hostname")
+				format, _ = cmd.Flags().GetString("format")
+				var err error
+				owner, err = config.FromContext(cmd.Context()).OrganizationArg(args, 0)
+				return err
+			},
+		}
+		probe.Flags().String("hostname", "github.com", "")
+		probe.Flags().String("format", "csv", "")
+		root.AddCommand(probe)
+		root.SetArgs(append([]string{"probe"}, args...))
+		root.SetOut(io.Discard)
+		root.SetErr(&stderr)
+		return hostname, owner, root.Execute()
+	}
+
+	if hostname, owner, err := run("--profile", "ghes"); err != nil || hostname != "github.example.com" || owner != "my-org" || format != "json" {
+		t.Errorf("Expected profile defaults, got %q %q %q (%v)", hostname, owner, format, err)
+	}
+	if want := "Using profile ghes from " + userFile + ", " + config.DirectoryFile; !strings.Contains(stderr.String(), want) {
+		t.Errorf("Expected the files of the profile to be reported, got %q", stderr.String())
+	}
+	if hostname, owner, err := run("--profile", "ghes", "--hostname", "other.example.com", "other-org"); err != nil || hostname != "other.example.com" || owner != "other-org" {
+		t.Errorf("Expected flags and arguments to override the profile, got %q %q (%v)", hostname, owner, err)
+	}
+	if _, _, err := run("--profile", "missing"); err == nil {
+		t.Error("Expected error for an unknown profile")
+	}
+
+	// The directory file can't choose the organization, host or credentials
+	for setting, content := range map[string]string{
+		"token":        "profiles:\n  ghes:\n    token: stolen\n",
+		"organization": "profiles:\n  ghes:\n    organization: other-org\n",
+	} {
+		if err := os.WriteFile(config.DirectoryFile, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := run("--profile", "ghes"); err == nil || !strings.Contains(err.Error(), setting+" can only be set in the user's config file") {
+			t.Errorf("Expected the directory file's %s to be rejected, got %v", setting, err)
+		}
+	}
+}
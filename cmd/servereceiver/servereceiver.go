@@ -0,0 +1,105 @@
+package servereceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/term"
+	"github.com/katiem0/gh-organization-webhooks/internal/log"
+	"github.com/katiem0/gh-organization-webhooks/internal/receiver"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type receiverCmdFlags struct {
+	addr      string
+	path      string
+	secret    string
+	allowSHA1 bool
+	debug     bool
+}
+
+func NewCmdServeReceiver() *cobra.Command {
+	receiverCmdFlags := receiverCmdFlags{}
+
+	receiverCmd := &cobra.Command{
+		Use:   "serve-receiver [flags]",
+		Short: "Run a local server that receives and verifies webhook deliveries",
+		Long:  "Run a local HTTP server that accepts webhook deliveries, verifies the X-Hub-Signature-256 header and prints each delivery",
+		Args:  cobra.NoArgs,
+		RunE: func(receiverCmd *cobra.Command, args []string) error {
+			// Reinitialize logging if debugging was enabled
+			if receiverCmdFlags.debug {
+				logger, _ := log.NewLogger(receiverCmdFlags.debug)
+				defer logger.Sync() // nolint:errcheck
+				zap.ReplaceGlobals(logger)
+			}
+
+			if receiverCmdFlags.secret == "" {
+				receiverCmdFlags.secret = os.Getenv("WEBHOOK_SECRET")
+			}
+
+			listener, err := net.Listen("tcp", receiverCmdFlags.addr)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			return runCmdServeReceiver(ctx, listener, &receiverCmdFlags)
+		},
+	}
+
+	// Configure flags for command
+	receiverCmd.Flags().StringVarP(&receiverCmdFlags.addr, "addr", "a", "127.0.0.1:8080", "Address to listen on")
+	receiverCmd.Flags().StringVarP(&receiverCmdFlags.path, "path", "", "/", "URL path that accepts deliveries")
+	receiverCmd.Flags().StringVarP(&receiverCmdFlags.secret, "secret", "s", "", `Webhook secret used to verify signatures (default "$WEBHOOK_SECRET")`)
+	receiverCmd.Flags().BoolVarP(&receiverCmdFlags.allowSHA1, "allow-sha1", "", false, "Accept the legacy HMAC-SHA1 X-Hub-Signature header from deliveries without X-Hub-Signature-256")
+	receiverCmd.PersistentFlags().BoolVarP(&receiverCmdFlags.debug, "debug", "d", false, "To debug logging")
+
+	return receiverCmd
+}
+
+func runCmdServeReceiver(ctx context.Context, listener net.Listener, flags *receiverCmdFlags) error {
+	terminal := term.FromEnv()
+	mux := http.NewServeMux()
+	mux.Handle(flags.path, &receiver.Handler{
+		Secret:    flags.secret,
+		AllowSHA1: flags.allowSHA1,
+		Out:       terminal.Out(),
+		Colorize:  terminal.IsColorEnabled(),
+	})
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if flags.secret == "" {
+		zap.S().Warn("No secret configured, deliveries will not be verified")
+	}
+	fmt.Fprintf(os.Stderr, "Listening for webhook deliveries on http://%s%s (Ctrl-C to stop)\n", listener.Addr(), flags.path)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
@@ -0,0 +1,60 @@
+package servereceiver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewCmdServeReceiver(t *testing.T) {
+	cmd := NewCmdServeReceiver()
+
+	if cmd == nil {
+		t.Fatal("NewCmdServeReceiver() returned nil")
+	}
+
+	if cmd.Use != "serve-receiver [flags]" {
+		t.Errorf("Expected Use to be 'serve-receiver [flags]', got %s", cmd.Use)
+	}
+
+	for _, name := range []string{"addr", "path", "secret", "allow-sha1", "debug"} {
+		if cmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+}
+
+func TestRunCmdServeReceiver(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- runCmdServeReceiver(ctx, listener, &receiverCmdFlags{path: "/webhook", secret: "s3cret"})
+	}()
+
+	resp, err := http.Post("http://"+listener.Addr().String()+"/webhook", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("Failed to deliver: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected unsigned delivery to be rejected, got %d", resp.StatusCode)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server did not shut down")
+	}
+}
@@ -0,0 +1,142 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-organization-webhooks/internal/client"
+	"github.com/katiem0/gh-organization-webhooks/internal/config"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/log"
+	"github.com/katiem0/gh-organization-webhooks/internal/snapshot"
+	"github.com/katiem0/gh-organization-webhooks/internal/version"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type snapshotCmdFlags struct {
+	token          string
+	hostname       string
+	appID          int64
+	appPrivateKey  string
+	installationID int64
+	outputFile     string
+	timeout        time.Duration
+	requestTimeout time.Duration
+	debug          bool
+}
+
+type webhookGetter interface {
+	GetOrganizationWebhooks(ctx context.Context, owner string) ([]byte, error)
+}
+
+func NewCmdSnapshot() *cobra.Command {
+	snapshotCmdFlags := snapshotCmdFlags{}
+
+	snapshotCmd := &cobra.Command{
+		Use:   "snapshot [<organization>] [flags]",
+		Short: "Save the webhook configuration of an organization",
+		Long:  "Save the configuration of every webhook in an organization to a versioned JSON snapshot that can be restored later",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(snapshotCmd *cobra.Command, args []string) error {
+			var err error
+			var restClient *api.RESTClient
+
+			// Reinitialize logging if debugging was enabled
+			if snapshotCmdFlags.debug {
+				logger, _ := log.NewLogger(snapshotCmdFlags.debug)
+				defer logger.Sync() // nolint:errcheck
+				zap.ReplaceGlobals(logger)
+			}
+
+			restClient, err = client.NewRESTClient(client.Options{
+				Hostname:       snapshotCmdFlags.hostname,
+				Token:          snapshotCmdFlags.token,
+				AppID:          snapshotCmdFlags.appID,
+				AppPrivateKey:  snapshotCmdFlags.appPrivateKey,
+				InstallationID: snapshotCmdFlags.installationID,
+				RequestTimeout: snapshotCmdFlags.requestTimeout,
+			})
+			if err != nil {
+				zap.S().Errorf("Error arose retrieving rest client: %v", err)
+				return err
+			}
+
+			ctx, cancel := client.CommandContext(snapshotCmdFlags.timeout)
+			defer cancel()
+
+			owner, err := config.FromContext(snapshotCmd.Context()).OrganizationArg(args, 0)
+			if err != nil {
+				return err
+			}
+			snapshotCmdFlags.outputFile = snapshotFile(snapshotCmd, snapshotCmdFlags.outputFile, owner)
+
+			s, err := runCmdSnapshot(ctx, owner, snapshotCmdFlags.hostname, data.NewAPIGetter(restClient))
+			if err != nil {
+				return err
+			}
+
+			f, err := os.OpenFile(snapshotCmdFlags.outputFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+			if err != nil {
+				zap.S().Errorf("Error opening file: %v", err)
+				return err
+			}
+			if err := writeSnapshot(f, s); err != nil {
+				return err
+			}
+			fmt.Printf("Saved %d webhooks for %s to %s\n", len(s.Webhooks), owner, snapshotCmdFlags.outputFile)
+			return nil
+		},
+	}
+
+	// Configure flags for command
+	snapshotCmd.PersistentFlags().StringVarP(&snapshotCmdFlags.token, "token", "t", "", `GitHub personal access token for reading the organization (default "gh auth token")`)
+	snapshotCmd.PersistentFlags().StringVarP(&snapshotCmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
+	snapshotCmd.PersistentFlags().Int64VarP(&snapshotCmdFlags.appID, "app-id", "", 0, "GitHub App ID used to authenticate instead of a token (Requires --app-private-key and --installation-id)")
+	snapshotCmd.PersistentFlags().StringVarP(&snapshotCmdFlags.appPrivateKey, "app-private-key", "", "", "Path to the GitHub App private key PEM file")
+	snapshotCmd.PersistentFlags().Int64VarP(&snapshotCmdFlags.installationID, "installation-id", "", 0, "GitHub App installation ID for the organization")
+	snapshotCmd.Flags().StringVarP(&snapshotCmdFlags.outputFile, "output-file", "o", "WebhookSnapshot-<organization>-<timestamp>.json", "Name of file to write the snapshot to")
+	client.AddTimeoutFlags(snapshotCmd.PersistentFlags(), &snapshotCmdFlags.timeout, &snapshotCmdFlags.requestTimeout)
+	snapshotCmd.PersistentFlags().BoolVarP(&snapshotCmdFlags.debug, "debug", "d", false, "To debug logging")
+
+	return snapshotCmd
+}
+
+// snapshotFile returns the file to write the snapshot to, named after the
+// organization unless one was given on the command line or in the profile
+func snapshotFile(cmd *cobra.Command, outputFile, owner string) string {
+	if config.IsSet(cmd, "output-file") {
+		return outputFile
+	}
+	return fmt.Sprintf("WebhookSnapshot-%s-%s.json", owner, time.Now().Format("20060102150405"))
+}
+
+// runCmdSnapshot reads the organization's webhooks into a snapshot
+func runCmdSnapshot(ctx context.Context, owner, hostname string, g webhookGetter) (*snapshot.Snapshot, error) {
+	zap.S().Debugf("Gathering webhooks for %s", owner)
+	orgWebhooks, err := g.GetOrganizationWebhooks(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	var webhooks []data.Webhook
+	if err := json.Unmarshal(orgWebhooks, &webhooks); err != nil {
+		return nil, fmt.Errorf("unable to parse webhooks for %s: %w", owner, err)
+	}
+	return snapshot.New(hostname, owner, version.String(), webhooks), nil
+}
+
+// writeSnapshot writes the snapshot and closes w, so that a failed close
+// isn't mistaken for a saved snapshot
+func writeSnapshot(w io.WriteCloser, s *snapshot.Snapshot) error {
+	if err := s.Write(w); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
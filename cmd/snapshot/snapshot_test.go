@@ -0,0 +1,89 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/config"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/snapshot"
+)
+
+func TestNewCmdSnapshot(t *testing.T) {
+	cmd := NewCmdSnapshot()
+
+	if cmd == nil {
+		t.Fatal("NewCmdSnapshot() returned nil")
+	}
+
+	if cmd.Use != "snapshot [<organization>] [flags]" {
+		t.Errorf("Expected Use to be 'snapshot [<organization>] [flags]', got %s", cmd.Use)
+	}
+
+	for _, name := range []string{"token", "hostname", "app-id", "app-private-key", "installation-id", "output-file", "timeout", "request-timeout", "debug"} {
+		if cmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+}
+
+func TestRunCmdSnapshot(t *testing.T) {
+	g := data.NewMockAPIGetter()
+	g.OrganizationWebhooksData = []byte(`[
+		{"type":"Organization","id":1,"name":"web","active":true,"events":["push"],
+		 "config":{"content_type":"json","insecure_ssl":"0","secret":"********","url":"https://ci.example.com/github"}}
+	]`)
+
+	s, err := runCmdSnapshot(context.Background(), "my-org", "github.example.com", g)
+	if err != nil {
+		t.Fatalf("runCmdSnapshot() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSnapshot(f, s); err != nil {
+		t.Fatalf("writeSnapshot() error = %v", err)
+	}
+
+	loaded, err := snapshot.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Hostname != "github.example.com" || loaded.Organization != "my-org" || loaded.ToolVersion == "" {
+		t.Errorf("Unexpected snapshot header %+v", loaded)
+	}
+	if len(loaded.Webhooks) != 1 || loaded.Webhooks[0].Config.Url != "https://ci.example.com/github" {
+		t.Errorf("Unexpected snapshot webhooks %+v", loaded.Webhooks)
+	}
+}
+
+func TestRunCmdSnapshotError(t *testing.T) {
+	g := data.NewMockAPIGetter()
+	g.MethodErrors = map[string]error{"GetOrganizationWebhooks": data.ErrForbidden}
+
+	if _, err := runCmdSnapshot(context.Background(), "my-org", "github.com", g); !errors.Is(err, data.ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestSnapshotFile(t *testing.T) {
+	cmd := NewCmdSnapshot()
+	if got := snapshotFile(cmd, cmd.Flag("output-file").Value.String(), "test-org"); !strings.HasPrefix(got, "WebhookSnapshot-test-org-") {
+		t.Errorf("Expected the default file to be named after the organization, got %q", got)
+	}
+
+	p := config.Profile{Name: "backup", Flags: map[string]map[string]string{"snapshot": {"output-file": "snapshots/test-org.json"}}}
+	if err := p.Apply(cmd); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got := snapshotFile(cmd, cmd.Flag("output-file").Value.String(), "test-org"); got != "snapshots/test-org.json" {
+		t.Errorf("Expected the profile's output file, got %q", got)
+	}
+}
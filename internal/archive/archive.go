@@ -0,0 +1,164 @@
+// Package archive stores webhook deliveries in a local, date-partitioned
+// archive of gzipped JSON files with a JSON lines index.
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+)
+
+// IndexFile is the name of the index within the archive directory.
+const IndexFile = "index.jsonl"
+
+// Entry is a line of the index describing one archived delivery.
+type Entry struct {
+	Organization string    `json:"organization"`
+	HookID       int64     `json:"hook_id"`
+	DeliveryID   int64     `json:"delivery_id"`
+	GUID         string    `json:"guid"`
+	Event        string    `json:"event"`
+	Action       string    `json:"action,omitempty"`
+	StatusCode   int       `json:"status_code"`
+	Redelivery   bool      `json:"redelivery"`
+	DeliveredAt  time.Time `json:"delivered_at"`
+	Path         string    `json:"path"`
+}
+
+// Archive is an archive directory opened for appending.
+type Archive struct {
+	Dir   string
+	last  map[string]int64
+	index *os.File
+}
+
+// Open opens or creates the archive in dir and reads its index to find the
+// last archived delivery of each hook.
+func Open(dir string) (*Archive, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	a := &Archive{Dir: dir, last: map[string]int64{}}
+
+	entries, err := ReadIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		key := hookKey(e.Organization, e.HookID)
+		if e.DeliveryID > a.last[key] {
+			a.last[key] = e.DeliveryID
+		}
+	}
+
+	a.index, err = os.OpenFile(filepath.Join(dir, IndexFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// ReadIndex returns the entries of the archive index in dir.
+func ReadIndex(dir string) ([]Entry, error) {
+	f, err := os.Open(filepath.Join(dir, IndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint:errcheck
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", IndexFile, line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// LastDeliveryID returns the newest archived delivery ID for the hook, or
+// zero when nothing has been archived.
+func (a *Archive) LastDeliveryID(owner string, hookID int64) int64 {
+	return a.last[hookKey(owner, hookID)]
+}
+
+// Add writes the raw delivery JSON to YYYY/MM/DD/<hook-id>-<delivery-id>.json.gz,
+// partitioned by the UTC delivery date, and appends it to the index.
+func (a *Archive) Add(owner string, hookID int64, d data.HookDelivery, raw []byte) (Entry, error) {
+	delivered := d.DeliveredAt.UTC()
+	rel := filepath.Join(delivered.Format("2006"), delivered.Format("01"), delivered.Format("02"),
+		fmt.Sprintf("%d-%d.json.gz", hookID, d.ID))
+	if err := writeGzip(filepath.Join(a.Dir, rel), raw); err != nil {
+		return Entry{}, err
+	}
+
+	e := Entry{
+		Organization: owner,
+		HookID:       hookID,
+		DeliveryID:   d.ID,
+		GUID:         d.GUID,
+		Event:        d.Event,
+		Action:       d.Action,
+		StatusCode:   d.StatusCode,
+		Redelivery:   d.Redelivery,
+		DeliveredAt:  d.DeliveredAt,
+		Path:         filepath.ToSlash(rel),
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return e, err
+	}
+	if _, err := a.index.Write(append(line, '\n')); err != nil {
+		return e, err
+	}
+
+	key := hookKey(owner, hookID)
+	if d.ID > a.last[key] {
+		a.last[key] = d.ID
+	}
+	return e, nil
+}
+
+// Close closes the index.
+func (a *Archive) Close() error {
+	return a.index.Close()
+}
+
+func writeGzip(path string, raw []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write(raw); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func hookKey(owner string, hookID int64) string {
+	return fmt.Sprintf("%s/%d", owner, hookID)
+}
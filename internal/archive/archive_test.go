@@ -0,0 +1,77 @@
+package archive
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/relay"
+)
+
+func TestArchive(t *testing.T) {
+	dir := t.TempDir()
+
+	a, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got := a.LastDeliveryID("test-org", 7); got != 0 {
+		t.Errorf("Expected empty archive, got last ID %d", got)
+	}
+
+	raw := []byte(`{"id":42,"guid":"abc","event":"push","delivered_at":"2024-05-01T23:30:00-02:00","request":{"headers":{},"payload":{"ref":"main"}}}`)
+	var d data.HookDelivery
+	if err := json.Unmarshal(raw, &d); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := a.Add("test-org", 7, d, raw)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if entry.Path != "2024/05/02/7-42.json.gz" {
+		t.Errorf("Expected UTC date partition, got %s", entry.Path)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Archived files can be read back, e.g. by replay --file
+	archived, err := relay.LoadDelivery(filepath.Join(dir, entry.Path))
+	if err != nil {
+		t.Fatalf("LoadDelivery() error = %v", err)
+	}
+	if archived.ID != 42 || string(archived.Request.Payload) != `{"ref":"main"}` {
+		t.Errorf("Unexpected archived delivery %+v", archived)
+	}
+
+	// Reopening resumes from the index
+	a, err = Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer a.Close() // nolint:errcheck
+	if got := a.LastDeliveryID("test-org", 7); got != 42 {
+		t.Errorf("Expected last ID 42 from index, got %d", got)
+	}
+	if got := a.LastDeliveryID("other-org", 7); got != 0 {
+		t.Errorf("Expected hooks to be tracked per organization, got %d", got)
+	}
+
+	entries, err := ReadIndex(dir)
+	if err != nil || len(entries) != 1 || !entries[0].DeliveredAt.Equal(time.Date(2024, 5, 2, 1, 30, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected index %+v (%v)", entries, err)
+	}
+}
+
+func TestReadIndexCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, IndexFile), []byte("{not json}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(dir); err == nil {
+		t.Error("Expected error for corrupt index, got nil")
+	}
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// refreshWindow is how long before expiry an installation token is replaced.
+const refreshWindow = 5 * time.Minute
+
+// AppTokenSource mints GitHub App JWTs and exchanges them for installation
+// access tokens, refreshing the installation token before it expires.
+type AppTokenSource struct {
+	baseURL        string
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+	httpClient     *http.Client
+	now            func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewAppTokenSource creates a token source for a GitHub App installation.
+// privateKey is either the path to a PEM encoded private key or the PEM
+// encoded key itself.
+func NewAppTokenSource(hostname string, appID int64, privateKey string, installationID int64, transport http.RoundTripper) (*AppTokenSource, error) {
+	key, err := loadPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &AppTokenSource{
+		baseURL:        RESTBaseURL(hostname),
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		httpClient:     &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		now:            time.Now,
+	}, nil
+}
+
+// Token returns a valid installation access token, requesting a new one
+// when none has been issued yet or the current one is about to expire.
+func (s *AppTokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.now().Add(refreshWindow).Before(s.expiresAt) {
+		return s.token, nil
+	}
+
+	zap.S().Debugf("Requesting installation access token for app %d installation %d", s.appID, s.installationID)
+	jwt, err := s.signJWT()
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%sapp/installations/%d/access_tokens", s.baseURL, s.installationID)
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			zap.S().Errorf("Error closing response body: %v", err)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("unable to create installation access token for installation %d: status %d, body: %s", s.installationID, resp.StatusCode, string(body))
+	}
+
+	var accessToken struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(body, &accessToken); err != nil {
+		return "", err
+	}
+	if accessToken.Token == "" {
+		return "", errors.New("installation access token response did not include a token")
+	}
+
+	s.token = accessToken.Token
+	s.expiresAt = accessToken.ExpiresAt
+	return s.token, nil
+}
+
+// signJWT creates an RS256 signed JWT identifying the GitHub App. The issued
+// at time is backdated to allow for clock drift, as recommended by GitHub.
+func (s *AppTokenSource) signJWT() (string, error) {
+	now := s.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(s.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func loadPrivateKey(privateKey string) (*rsa.PrivateKey, error) {
+	pemData := []byte(privateKey)
+	if !strings.Contains(privateKey, "-----BEGIN") {
+		b, err := os.ReadFile(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read GitHub App private key: %w", err)
+		}
+		pemData = b
+	}
+
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("GitHub App private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key must be an RSA key")
+	}
+	return key, nil
+}
+
+// appTransport sets a current installation access token on every request.
+type appTransport struct {
+	source *AppTokenSource
+	base   http.RoundTripper
+}
+
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+token)
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,185 @@
+package client
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+	}
+}
+
+func testKey(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return key, string(pemKey)
+}
+
+func TestLoadPrivateKey(t *testing.T) {
+	key, pemKey := testKey(t)
+
+	t.Run("inline PEM", func(t *testing.T) {
+		loaded, err := loadPrivateKey(pemKey)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !loaded.Equal(key) {
+			t.Error("Loaded key does not match")
+		}
+	})
+
+	t.Run("PKCS8 file", func(t *testing.T) {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatalf("Failed to marshal key: %v", err)
+		}
+		path := filepath.Join(t.TempDir(), "app.pem")
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+			t.Fatalf("Failed to write key: %v", err)
+		}
+		loaded, err := loadPrivateKey(path)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !loaded.Equal(key) {
+			t.Error("Loaded key does not match")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := loadPrivateKey(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+}
+
+func TestAppTokenSourceToken(t *testing.T) {
+	key, pemKey := testKey(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	requests := 0
+
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		if req.URL.String() != "https://api.github.com/app/installations/99/access_tokens" {
+			t.Errorf("Unexpected URL %s", req.URL)
+		}
+		jwt := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		parts := strings.Split(jwt, ".")
+		if len(parts) != 3 {
+			t.Fatalf("Expected JWT with 3 parts, got %d", len(parts))
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			t.Errorf("JWT signature did not verify: %v", err)
+		}
+		claimBytes, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		var claims map[string]interface{}
+		_ = json.Unmarshal(claimBytes, &claims)
+		if claims["iss"] != "42" {
+			t.Errorf("Expected iss 42, got %v", claims["iss"])
+		}
+		return jsonResponse(http.StatusCreated, fmt.Sprintf(`{"token":"ghs_%d","expires_at":"%s"}`, requests, now.Add(time.Hour).Format(time.RFC3339))), nil
+	})
+
+	source, err := NewAppTokenSource("github.com", 42, pemKey, 99, transport)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	source.now = func() time.Time { return now }
+
+	token, err := source.Token()
+	if err != nil || token != "ghs_1" {
+		t.Fatalf("Expected ghs_1, got %s (%v)", token, err)
+	}
+
+	// A cached token is reused until it nears expiry
+	token, _ = source.Token()
+	if token != "ghs_1" || requests != 1 {
+		t.Errorf("Expected cached token, got %s after %d requests", token, requests)
+	}
+
+	now = now.Add(56 * time.Minute)
+	token, _ = source.Token()
+	if token != "ghs_2" {
+		t.Errorf("Expected refreshed token ghs_2, got %s", token)
+	}
+}
+
+func TestAppTokenSourceTokenError(t *testing.T) {
+	_, pemKey := testKey(t)
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusNotFound, `{"message":"Not Found"}`), nil
+	})
+
+	source, err := NewAppTokenSource("github.com", 42, pemKey, 99, transport)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := source.Token(); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestNewRESTClientWithApp(t *testing.T) {
+	_, pemKey := testKey(t)
+	var authorization string
+
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.HasSuffix(req.URL.Path, "/access_tokens") {
+			return jsonResponse(http.StatusCreated, `{"token":"ghs_installation","expires_at":"2999-01-01T00:00:00Z"}`), nil
+		}
+		authorization = req.Header.Get("Authorization")
+		return jsonResponse(http.StatusOK, `[]`), nil
+	})
+
+	restClient, err := NewRESTClient(Options{
+		Hostname:       "ghes.example.com",
+		AppID:          42,
+		AppPrivateKey:  pemKey,
+		InstallationID: 99,
+		Transport:      transport,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	resp, err := restClient.Request("GET", "orgs/test-org/hooks", nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if authorization != "token ghs_installation" {
+		t.Errorf("Expected installation token authorization, got %q", authorization)
+	}
+}
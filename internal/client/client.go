@@ -0,0 +1,100 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/cli/go-gh/v2/pkg/auth"
+)
+
+// DefaultRequestTimeout is how long a single API request may take.
+const DefaultRequestTimeout = time.Minute
+
+// Options describes the host and credentials used to build a REST client.
+// Either a token or a complete set of GitHub App credentials is used; when
+// neither is provided the token stored by `gh auth` for the host is used.
+type Options struct {
+	Hostname       string
+	Token          string
+	AppID          int64
+	AppPrivateKey  string
+	InstallationID int64
+	Transport      http.RoundTripper
+	WritePacing    WritePacing
+	// RequestTimeout limits each attempt of an API request, 0 for no limit
+	RequestTimeout time.Duration
+}
+
+// UsesApp reports whether any GitHub App credential was provided.
+func (o Options) UsesApp() bool {
+	return o.AppID != 0 || o.AppPrivateKey != "" || o.InstallationID != 0
+}
+
+// HasCredentials reports whether a token or GitHub App credentials were provided.
+func (o Options) HasCredentials() bool {
+	return o.Token != "" || o.UsesApp()
+}
+
+// Validate checks that GitHub App credentials are complete and not mixed with a token.
+func (o Options) Validate() error {
+	if !o.UsesApp() {
+		return nil
+	}
+	if o.Token != "" {
+		return errors.New("specify only one of a personal access token or GitHub App credentials")
+	}
+	if o.AppID == 0 || o.AppPrivateKey == "" || o.InstallationID == 0 {
+		return errors.New("a GitHub App ID, private key and installation ID must all be specified")
+	}
+	return nil
+}
+
+// NewRESTClient returns a REST client for the host authenticated with the
+// configured credentials.
+func NewRESTClient(opts Options) (*api.RESTClient, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	clientOpts := api.ClientOptions{
+		Headers: map[string]string{
+			"Accept": "application/vnd.github+json",
+		},
+		Host:      opts.Hostname,
+		AuthToken: opts.Token,
+		Transport: opts.Transport,
+	}
+
+	if opts.UsesApp() {
+		source, err := NewAppTokenSource(opts.Hostname, opts.AppID, opts.AppPrivateKey, opts.InstallationID, opts.Transport)
+		if err != nil {
+			return nil, err
+		}
+		token, err := source.Token()
+		if err != nil {
+			return nil, err
+		}
+		clientOpts.AuthToken = token
+		clientOpts.Transport = &appTransport{source: source, base: opts.Transport}
+	} else if clientOpts.AuthToken == "" {
+		t, _ := auth.TokenForHost(opts.Hostname)
+		clientOpts.AuthToken = t
+	}
+
+	// Retries sit outside the App transport so every attempt carries a current token,
+	// and pacing sits outside retries so a retried write keeps its place in the schedule
+	clientOpts.Transport = newPacingTransport(newRetryTransport(clientOpts.Transport, opts.RequestTimeout), opts.WritePacing)
+
+	return api.NewRESTClient(clientOpts)
+}
+
+// RESTBaseURL returns the REST API base URL, with a trailing slash, for the host.
+func RESTBaseURL(hostname string) string {
+	hostname = auth.NormalizeHostname(hostname)
+	if auth.IsEnterprise(hostname) {
+		return "https://" + hostname + "/api/v3/"
+	}
+	return "https://api." + hostname + "/"
+}
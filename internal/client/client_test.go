@@ -0,0 +1,41 @@
+package client
+
+import (
+	"testing"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{name: "token only", opts: Options{Token: "ghp_token"}},
+		{name: "no credentials", opts: Options{}},
+		{name: "complete app", opts: Options{AppID: 1, AppPrivateKey: "key.pem", InstallationID: 2}},
+		{name: "partial app", opts: Options{AppID: 1}, wantErr: true},
+		{name: "token and app", opts: Options{Token: "ghp_token", AppID: 1, AppPrivateKey: "key.pem", InstallationID: 2}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRESTBaseURL(t *testing.T) {
+	tests := map[string]string{
+		"github.com":       "https://api.github.com/",
+		"ghes.example.com": "https://ghes.example.com/api/v3/",
+		"octocorp.ghe.com": "https://api.octocorp.ghe.com/",
+	}
+	for host, want := range tests {
+		if got := RESTBaseURL(host); got != want {
+			t.Errorf("RESTBaseURL(%s) = %s, want %s", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// AddTimeoutFlags registers --timeout, which limits the whole command, and
+// --request-timeout on flags.
+func AddTimeoutFlags(flags *pflag.FlagSet, timeout, requestTimeout *time.Duration) {
+	AddCommandTimeoutFlag(flags, timeout)
+	AddRequestTimeoutFlag(flags, requestTimeout)
+}
+
+// AddCommandTimeoutFlag registers --timeout, which limits the whole command,
+// on flags, for commands that don't call the GitHub API.
+func AddCommandTimeoutFlag(flags *pflag.FlagSet, timeout *time.Duration) {
+	flags.DurationVarP(timeout, "timeout", "", 0, "Maximum time for the whole command (0 for no limit)")
+}
+
+// AddRequestTimeoutFlag registers --request-timeout, which limits each API
+// request, on flags.
+func AddRequestTimeoutFlag(flags *pflag.FlagSet, requestTimeout *time.Duration) {
+	flags.DurationVarP(requestTimeout, "request-timeout", "", DefaultRequestTimeout, "Maximum time for each API request (0 for no limit)")
+}
+
+// CommandContext returns the context for a command, which is canceled on an
+// interrupt and, when timeout is greater than zero, once timeout has passed.
+func CommandContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// WithTimeout returns ctx limited to timeout, or ctx itself when timeout is
+// not greater than zero.
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
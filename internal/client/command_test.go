@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestCommandContext(t *testing.T) {
+	ctx, cancel := CommandContext(0)
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Expected no deadline without a timeout")
+	}
+	cancel()
+	if ctx.Err() == nil {
+		t.Error("Expected the context to be canceled")
+	}
+
+	ctx, cancel = CommandContext(time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("Expected the timeout to expire, got %v", ctx.Err())
+	}
+}
+
+func TestAddTimeoutFlags(t *testing.T) {
+	var timeout, requestTimeout time.Duration
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddTimeoutFlags(flags, &timeout, &requestTimeout)
+
+	if err := flags.Parse([]string{"--timeout", "5m"}); err != nil {
+		t.Fatal(err)
+	}
+	if timeout != 5*time.Minute || requestTimeout != DefaultRequestTimeout {
+		t.Errorf("Unexpected timeouts %s and %s", timeout, requestTimeout)
+	}
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultWriteRate follows GitHub's guidance of waiting at least a second
+// between content creating requests.
+const DefaultWriteRate = 1.0
+
+var orgPathRE = regexp.MustCompile(`/orgs/([^/]+)`)
+
+// WritePacing limits how quickly POST, PATCH, PUT and DELETE requests are
+// sent so that bulk changes don't trip GitHub's secondary rate limits.
+type WritePacing struct {
+	// Rate is the maximum writes per second across all organizations, 0 for no limit
+	Rate float64
+	// Spacing is the minimum time between writes to the same organization
+	Spacing time.Duration
+	// Concurrency is the number of writes in flight per organization, at least 1
+	Concurrency int
+}
+
+// pacingTransport schedules write requests according to a WritePacing.
+// Reads are passed straight through.
+type pacingTransport struct {
+	base   http.RoundTripper
+	pacing WritePacing
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+
+	mu   sync.Mutex
+	next time.Time
+	orgs map[string]*orgSchedule
+}
+
+type orgSchedule struct {
+	slots chan struct{}
+	next  time.Time
+}
+
+func newPacingTransport(base http.RoundTripper, pacing WritePacing) *pacingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if pacing.Concurrency < 1 {
+		pacing.Concurrency = 1
+	}
+	return &pacingTransport{
+		base:   base,
+		pacing: pacing,
+		now:    time.Now,
+		sleep:  sleepContext,
+		orgs:   map[string]*orgSchedule{},
+	}
+}
+
+func (t *pacingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isWrite(req.Method) {
+		return t.base.RoundTrip(req)
+	}
+
+	org := ""
+	if m := orgPathRE.FindStringSubmatch(req.URL.Path); m != nil {
+		org = m[1]
+	}
+	schedule := t.schedule(org)
+
+	select {
+	case schedule.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	defer func() { <-schedule.slots }()
+
+	if wait := t.reserve(schedule); wait > 0 {
+		zap.S().Debugf("Pacing %s %s, waiting %s", req.Method, req.URL.Path, wait.Round(time.Millisecond))
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+func (t *pacingTransport) schedule(org string) *orgSchedule {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.orgs[org]
+	if !ok {
+		s = &orgSchedule{slots: make(chan struct{}, t.pacing.Concurrency)}
+		t.orgs[org] = s
+	}
+	return s
+}
+
+// reserve books the earliest start time allowed by the global rate and the
+// organization's spacing, returning how long to wait for it.
+func (t *pacingTransport) reserve(s *orgSchedule) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	start := now
+	if t.next.After(start) {
+		start = t.next
+	}
+	if s.next.After(start) {
+		start = s.next
+	}
+
+	if t.pacing.Rate > 0 {
+		t.next = start.Add(time.Duration(float64(time.Second) / t.pacing.Rate))
+	}
+	s.next = start.Add(t.pacing.Spacing)
+	return start.Sub(now)
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPatch, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
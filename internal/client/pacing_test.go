@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestPacingTransport returns a pacing transport on a fake clock that
+// advances when the transport sleeps
+func newTestPacingTransport(pacing WritePacing, base roundTripFunc) (*pacingTransport, *[]time.Duration) {
+	clock := time.Unix(1700000000, 0)
+	var waits []time.Duration
+	t := newPacingTransport(base, pacing)
+	t.now = func() time.Time { return clock }
+	t.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		clock = clock.Add(d)
+		return nil
+	}
+	return t, &waits
+}
+
+func okTransport(req *http.Request) (*http.Response, error) {
+	return jsonResponse(http.StatusOK, `{}`), nil
+}
+
+func send(t *testing.T, rt http.RoundTripper, method, url string) {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestPacingTransportRate(t *testing.T) {
+	transport, waits := newTestPacingTransport(WritePacing{Rate: 2}, okTransport)
+
+	send(t, transport, http.MethodPost, "https://api.github.com/orgs/org-a/hooks")
+	send(t, transport, http.MethodGet, "https://api.github.com/orgs/org-a/hooks")
+	send(t, transport, http.MethodPatch, "https://api.github.com/orgs/org-b/hooks/1")
+	send(t, transport, http.MethodDelete, "https://api.github.com/orgs/org-a/hooks/1")
+
+	// Reads are not paced, and the rate applies across organizations
+	want := []time.Duration{500 * time.Millisecond, 500 * time.Millisecond}
+	if len(*waits) != len(want) || (*waits)[0] != want[0] || (*waits)[1] != want[1] {
+		t.Errorf("Expected waits %v, got %v", want, *waits)
+	}
+}
+
+func TestPacingTransportSpacing(t *testing.T) {
+	transport, waits := newTestPacingTransport(WritePacing{Spacing: 3 * time.Second}, okTransport)
+
+	send(t, transport, http.MethodPost, "https://api.github.com/orgs/org-a/hooks")
+	send(t, transport, http.MethodPost, "https://api.github.com/orgs/org-b/hooks")
+	send(t, transport, http.MethodPost, "https://ghes.example.com/api/v3/orgs/org-a/hooks")
+
+	// Spacing is per organization, so only the second write to org-a waits
+	if len(*waits) != 1 || (*waits)[0] != 3*time.Second {
+		t.Errorf("Expected a single 3s wait, got %v", *waits)
+	}
+}
+
+func TestPacingTransportConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	inFlight := map[string]int{}
+	maxInFlight := map[string]int{}
+	transport := newPacingTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		org := orgPathRE.FindStringSubmatch(req.URL.Path)[1]
+		mu.Lock()
+		inFlight[org]++
+		maxInFlight[org] = max(maxInFlight[org], inFlight[org])
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight[org]--
+		mu.Unlock()
+		return jsonResponse(http.StatusCreated, `{}`), nil
+	}), WritePacing{})
+
+	var wg sync.WaitGroup
+	for range 3 {
+		for _, org := range []string{"org-a", "org-b"} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				send(t, transport, http.MethodPost, "https://api.github.com/orgs/"+org+"/hooks")
+			}()
+		}
+	}
+	wg.Wait()
+
+	if maxInFlight["org-a"] != 1 || maxInFlight["org-b"] != 1 {
+		t.Errorf("Expected one write in flight per organization, got %v", maxInFlight)
+	}
+}
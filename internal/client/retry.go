@@ -0,0 +1,224 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultMaxRetries is how many times a request is retried after the first attempt.
+	defaultMaxRetries = 4
+	// baseBackoff and maxBackoff bound the exponential backoff between attempts.
+	baseBackoff = time.Second
+	maxBackoff  = 30 * time.Second
+	// secondaryRateLimitWait is GitHub's recommended wait for a secondary
+	// rate limit that does not include a Retry-After header.
+	secondaryRateLimitWait = time.Minute
+)
+
+// retryTransport retries requests that failed because of rate limits or
+// transient server errors. Idempotent requests are retried on any of these
+// failures. Other requests, such as the POST creating a hook, are only
+// retried when GitHub rejected them because of a rate limit, as then no
+// hook was created.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	// timeout limits each attempt, so waiting to retry doesn't count against it
+	timeout time.Duration
+	now     func() time.Time
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+func newRetryTransport(base http.RoundTripper, timeout time.Duration) *retryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{
+		base:       base,
+		maxRetries: defaultMaxRetries,
+		timeout:    timeout,
+		now:        time.Now,
+		sleep:      sleepContext,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	idempotent := isIdempotent(req.Method)
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		cancel := context.CancelFunc(func() {})
+		if t.timeout > 0 {
+			var ctx context.Context
+			ctx, cancel = context.WithTimeout(req.Context(), t.timeout)
+			attemptReq = attemptReq.WithContext(ctx)
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if err == nil {
+			logRateLimit(req, resp)
+		}
+
+		retry, wait := t.shouldRetry(req, resp, err, idempotent, attempt)
+		if !retry || !replayable || attempt >= t.maxRetries {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			// The attempt's timeout also covers reading the body
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		if err != nil {
+			zap.S().Debugf("Retrying %s %s in %s after error: %v", req.Method, req.URL.Path, wait.Round(time.Millisecond), err)
+		} else {
+			zap.S().Debugf("Retrying %s %s in %s after status %d", req.Method, req.URL.Path, wait.Round(time.Millisecond), resp.StatusCode)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		cancel()
+		if wait > maxBackoff {
+			zap.S().Warnf("Rate limited by GitHub, waiting %s before retrying %s %s", wait.Round(time.Second), req.Method, req.URL.Path)
+		}
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// shouldRetry reports whether the attempt should be retried and how long to
+// wait before doing so.
+func (t *retryTransport) shouldRetry(req *http.Request, resp *http.Response, err error, idempotent bool, attempt int) (bool, time.Duration) {
+	if err != nil {
+		// The request may have reached GitHub, so only idempotent
+		// requests are retried after a transport error
+		if !idempotent || req.Context().Err() != nil {
+			return false, 0
+		}
+		return true, backoff(attempt)
+	}
+
+	if wait, limited := t.rateLimitWait(resp, attempt); limited {
+		return true, wait
+	}
+
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent, backoff(attempt)
+	}
+	return false, 0
+}
+
+// rateLimitWait reports whether the response is a primary or secondary rate
+// limit and how long GitHub asks clients to wait.
+func (t *retryTransport) rateLimitWait(resp *http.Response, attempt int) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return max(at.Sub(t.now()), 0), true
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			// Allow a second for clock skew between the client and GitHub
+			return max(time.Unix(reset, 0).Sub(t.now()), 0) + time.Second, true
+		}
+		return backoff(attempt), true
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || isSecondaryRateLimit(resp) {
+		return secondaryRateLimitWait + backoff(attempt), true
+	}
+	return 0, false
+}
+
+// isSecondaryRateLimit checks the body of a 403 response for GitHub's
+// secondary rate limit message, leaving the body readable by the caller.
+func isSecondaryRateLimit(resp *http.Response) bool {
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	message := strings.ToLower(string(body))
+	return strings.Contains(message, "secondary rate limit") || strings.Contains(message, "abuse detection")
+}
+
+func logRateLimit(req *http.Request, resp *http.Response) {
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		return
+	}
+	reset := resp.Header.Get("X-RateLimit-Reset")
+	if seconds, err := strconv.ParseInt(reset, 10, 64); err == nil {
+		reset = time.Unix(seconds, 0).Format(time.RFC3339)
+	}
+	zap.S().Debugf("Rate limit after %s %s: %s of %s requests remaining for %s, resets at %s",
+		req.Method, req.URL.Path, remaining, resp.Header.Get("X-RateLimit-Limit"),
+		resp.Header.Get("X-RateLimit-Resource"), reset)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff returns an exponential backoff with full jitter for the attempt.
+func backoff(attempt int) time.Duration {
+	ceiling := min(baseBackoff<<attempt, maxBackoff)
+	return time.Duration(rand.Int64N(int64(ceiling))) + time.Millisecond
+}
+
+// cancelOnClose releases an attempt's timeout once its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRetryTransport returns a retry transport that records waits instead of sleeping
+func newTestRetryTransport(base roundTripFunc, waits *[]time.Duration) *retryTransport {
+	t := newRetryTransport(base, 0)
+	t.now = func() time.Time { return time.Unix(1700000000, 0) }
+	t.sleep = func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return ctx.Err()
+	}
+	return t
+}
+
+func statusResponse(status int, header http.Header, body string) *http.Response {
+	resp := jsonResponse(status, body)
+	for k, v := range header {
+		resp.Header[k] = v
+	}
+	return resp
+}
+
+func TestRetryTransportServerErrors(t *testing.T) {
+	var waits []time.Duration
+	calls := 0
+	transport := newTestRetryTransport(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls < 3 {
+			return statusResponse(http.StatusBadGateway, nil, `{}`), nil
+		}
+		return statusResponse(http.StatusOK, nil, `[]`), nil
+	}, &waits)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/test-org/hooks", nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected GET to succeed after retries, got %v (%v)", resp, err)
+	}
+	if calls != 3 || len(waits) != 2 {
+		t.Errorf("Expected 3 attempts and 2 waits, got %d and %v", calls, waits)
+	}
+	for _, wait := range waits {
+		if wait <= 0 || wait > maxBackoff {
+			t.Errorf("Backoff %s outside (0, %s]", wait, maxBackoff)
+		}
+	}
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	var waits []time.Duration
+	calls := 0
+	transport := newTestRetryTransport(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return nil, errors.New("connection reset")
+	}, &waits)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/test-org/hooks", nil)
+	if _, err := transport.RoundTrip(req); err == nil {
+		t.Error("Expected error after exhausting retries")
+	}
+	if calls != defaultMaxRetries+1 {
+		t.Errorf("Expected %d attempts, got %d", defaultMaxRetries+1, calls)
+	}
+}
+
+func TestRetryTransportPost(t *testing.T) {
+	tests := []struct {
+		name      string
+		first     *http.Response
+		firstErr  error
+		wantCalls int
+	}{
+		{"server error may have created the hook", statusResponse(http.StatusBadGateway, nil, `{}`), nil, 1},
+		{"transport error may have created the hook", nil, errors.New("connection reset"), 1},
+		{"validation failure", statusResponse(http.StatusUnprocessableEntity, nil, `{}`), nil, 1},
+		{"secondary rate limit", statusResponse(http.StatusForbidden, nil, `{"message":"You have exceeded a secondary rate limit."}`), nil, 2},
+		{"too many requests", statusResponse(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"3"}}, `{}`), nil, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var waits []time.Duration
+			var bodies []string
+			transport := newTestRetryTransport(func(req *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(req.Body)
+				bodies = append(bodies, string(body))
+				if len(bodies) == 1 {
+					return tt.first, tt.firstErr
+				}
+				return statusResponse(http.StatusCreated, nil, `{"id":1}`), nil
+			}, &waits)
+
+			req, _ := http.NewRequest(http.MethodPost, "https://api.github.com/orgs/test-org/hooks", strings.NewReader(`{"name":"web"}`))
+			_, _ = transport.RoundTrip(req)
+			if len(bodies) != tt.wantCalls {
+				t.Fatalf("Expected %d attempts, got %d", tt.wantCalls, len(bodies))
+			}
+			for _, body := range bodies {
+				if body != `{"name":"web"}` {
+					t.Errorf("Expected body to be replayed, got %q", body)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryTransportRateLimitWait(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name   string
+		resp   *http.Response
+		want   time.Duration
+		exact  bool
+		limits bool
+	}{
+		{
+			name:   "retry after seconds",
+			resp:   statusResponse(http.StatusForbidden, http.Header{"Retry-After": []string{"30"}}, `{}`),
+			want:   30 * time.Second,
+			exact:  true,
+			limits: true,
+		},
+		{
+			name: "primary rate limit reset",
+			resp: statusResponse(http.StatusForbidden, http.Header{
+				"X-Ratelimit-Remaining": []string{"0"},
+				"X-Ratelimit-Reset":     []string{strconv.FormatInt(now.Add(90*time.Second).Unix(), 10)},
+			}, `{"message":"API rate limit exceeded"}`),
+			want:   91 * time.Second,
+			exact:  true,
+			limits: true,
+		},
+		{
+			name:   "secondary rate limit without retry after",
+			resp:   statusResponse(http.StatusForbidden, nil, `{"message":"You have exceeded a secondary rate limit"}`),
+			want:   secondaryRateLimitWait,
+			limits: true,
+		},
+		{
+			name: "missing permission",
+			resp: statusResponse(http.StatusForbidden, nil, `{"message":"Must have admin rights"}`),
+		},
+	}
+
+	transport := newRetryTransport(nil, 0)
+	transport.now = func() time.Time { return now }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, limited := transport.rateLimitWait(tt.resp, 0)
+			if limited != tt.limits {
+				t.Fatalf("Expected rate limited %v, got %v", tt.limits, limited)
+			}
+			if tt.exact && wait != tt.want {
+				t.Errorf("Expected wait %s, got %s", tt.want, wait)
+			}
+			if !tt.exact && wait < tt.want {
+				t.Errorf("Expected wait of at least %s, got %s", tt.want, wait)
+			}
+			// The body stays readable for the error returned to the caller
+			if body, _ := io.ReadAll(tt.resp.Body); len(body) == 0 {
+				t.Error("Expected response body to remain readable")
+			}
+		})
+	}
+}
+
+func TestRetryTransportCanceled(t *testing.T) {
+	transport := newRetryTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return statusResponse(http.StatusServiceUnavailable, nil, `{}`), nil
+	}), 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.github.com/orgs/test-org/hooks", nil)
+	if _, err := transport.RoundTrip(req); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context canceled while waiting to retry, got %v", err)
+	}
+}
+
+func TestRetryTransportAttemptTimeout(t *testing.T) {
+	var waits []time.Duration
+	calls := 0
+	transport := newTestRetryTransport(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		if _, ok := req.Context().Deadline(); !ok {
+			t.Error("Expected attempt to have a deadline")
+		}
+		return statusResponse(http.StatusOK, nil, `[]`), nil
+	}, &waits)
+	transport.timeout = 10 * time.Millisecond
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/test-org/hooks", nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Expected timed out attempt to be retried, got %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != `[]` {
+		t.Errorf("Expected body to be readable before the attempt is released, got %q (%v)", body, err)
+	}
+	_ = resp.Body.Close()
+	if calls != 2 {
+		t.Errorf("Expected 2 attempts, got %d", calls)
+	}
+}
@@ -0,0 +1,442 @@
+// Package config loads named profiles of default settings from the user's
+// configuration file and from a file in the working directory.
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// DirectoryFile is the name of the per-directory configuration file, whose
+// settings take precedence over the user's configuration file. As it may come
+// with a cloned repository, it can't set hosts, credentials or secrets.
+const DirectoryFile = ".gh-organization-webhooks.yaml"
+
+// directoryFlags are the flags a directory file may set. Flags that choose
+// organizations, hosts, credentials, webhook URLs, secrets or the files that
+// are read and written, or relax safety checks, can only be set in the user's
+// configuration file.
+var directoryFlags = map[string]bool{
+	"active": true, "concurrency": true, "content-type": true, "created-before": true,
+	"debug": true, "dry-run": true, "duration": true, "event": true, "fail-fast": true,
+	"fail-level": true, "format": true, "inactive": true, "interval": true, "jq": true,
+	"max-attempts": true, "request-timeout": true, "requests": true, "target-timeout": true,
+	"template": true, "timeout": true, "updated-since": true, "url-match": true,
+	"write-concurrency": true, "write-spacing": true,
+}
+
+// Config is the merged contents of the configuration files.
+type Config struct {
+	// DefaultProfile is used when --profile isn't given
+	DefaultProfile string             `yaml:"default_profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+	// Files are the configuration files that were read
+	Files []string `yaml:"-"`
+}
+
+// Profile is a named set of defaults. Token and secret values of the form
+// env:NAME are read from the environment variable NAME when used.
+type Profile struct {
+	Name               string `yaml:"-"`
+	Hostname           string `yaml:"hostname"`
+	Token              string `yaml:"token"`
+	AppID              int64  `yaml:"app_id"`
+	AppPrivateKey      string `yaml:"app_private_key"`
+	InstallationID     int64  `yaml:"installation_id"`
+	SourceHostname     string `yaml:"source_hostname"`
+	SourceToken        string `yaml:"source_token"`
+	SourceOrganization string `yaml:"source_organization"`
+	// Organization is used by commands when no organization argument is given
+	Organization string `yaml:"organization"`
+	// Format is the default report format of list
+	Format string `yaml:"format"`
+	// Secrets maps webhook URLs to the secrets to create them with
+	Secrets map[string]string `yaml:"secrets"`
+	// Rewrite changes the URLs of webhooks before they are created
+	Rewrite []Rewrite `yaml:"rewrite"`
+	// Flags sets the defaults of any other flags, keyed by command, e.g. "policy check"
+	Flags map[string]map[string]string `yaml:"flags"`
+}
+
+// Rewrite replaces the URL prefix From with To.
+type Rewrite struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// UserPath returns the user's configuration file,
+// $XDG_CONFIG_HOME/gh-organization-webhooks/config.yaml (~/.config when
+// unset), or an empty string when the home directory is unknown.
+func UserPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "gh-organization-webhooks", "config.yaml")
+}
+
+// LoadDefault reads the user's configuration file and then DirectoryFile in
+// the working directory, rejecting settings the directory file may not set.
+func LoadDefault() (*Config, error) {
+	c, err := Load(UserPath())
+	if err != nil {
+		return nil, err
+	}
+	f, err := readFile(DirectoryFile)
+	if err != nil || f == nil {
+		return c, err
+	}
+	if err := f.checkDirectory(); err != nil {
+		return nil, fmt.Errorf("%s: %w", DirectoryFile, err)
+	}
+	c.merge(f)
+	c.Files = append(c.Files, DirectoryFile)
+	return c, nil
+}
+
+// Load reads and merges the configuration files at paths, skipping files that
+// don't exist. Settings in later files override those in earlier ones.
+func Load(paths ...string) (*Config, error) {
+	c := &Config{Profiles: map[string]Profile{}}
+	for _, path := range paths {
+		f, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if f == nil {
+			continue
+		}
+		c.merge(f)
+		c.Files = append(c.Files, path)
+	}
+	return c, nil
+}
+
+// readFile parses the configuration file at path, returning nil when it
+// doesn't exist
+func readFile(path string) (*Config, error) {
+	if path == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file: %w", err)
+	}
+	f, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// checkDirectory returns an error for the first setting a directory file may
+// not set
+func (c *Config) checkDirectory() error {
+	if c.DefaultProfile != "" {
+		return errors.New("default_profile can only be set in the user's config file")
+	}
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := c.Profiles[name]
+		for _, setting := range []struct {
+			name string
+			set  bool
+		}{
+			{"hostname", p.Hostname != ""},
+			{"token", p.Token != ""},
+			{"app_id", p.AppID != 0},
+			{"app_private_key", p.AppPrivateKey != ""},
+			{"installation_id", p.InstallationID != 0},
+			{"source_hostname", p.SourceHostname != ""},
+			{"source_token", p.SourceToken != ""},
+			{"organization", p.Organization != ""},
+			{"source_organization", p.SourceOrganization != ""},
+			{"secrets", len(p.Secrets) > 0},
+			{"rewrite", len(p.Rewrite) > 0},
+		} {
+			if setting.set {
+				return fmt.Errorf("profile %s: %s can only be set in the user's config file", name, setting.name)
+			}
+		}
+		for command, values := range p.Flags {
+			for flag := range values {
+				if !directoryFlags[flag] {
+					return fmt.Errorf("profile %s: --%s of %s can only be set in the user's config file", name, flag, command)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Parse parses a YAML configuration file.
+func Parse(b []byte) (*Config, error) {
+	var c Config
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+	return &c, nil
+}
+
+func (c *Config) merge(o *Config) {
+	if o.DefaultProfile != "" {
+		c.DefaultProfile = o.DefaultProfile
+	}
+	for name, p := range o.Profiles {
+		c.Profiles[name] = c.Profiles[name].merge(p)
+	}
+}
+
+// merge returns p with the settings of o that are set
+func (p Profile) merge(o Profile) Profile {
+	setString := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
+	}
+	setString(&p.Hostname, o.Hostname)
+	setString(&p.Token, o.Token)
+	setString(&p.AppPrivateKey, o.AppPrivateKey)
+	setString(&p.SourceHostname, o.SourceHostname)
+	setString(&p.SourceToken, o.SourceToken)
+	setString(&p.SourceOrganization, o.SourceOrganization)
+	setString(&p.Organization, o.Organization)
+	setString(&p.Format, o.Format)
+	if o.AppID != 0 {
+		p.AppID = o.AppID
+	}
+	if o.InstallationID != 0 {
+		p.InstallationID = o.InstallationID
+	}
+	if len(o.Rewrite) > 0 {
+		p.Rewrite = o.Rewrite
+	}
+	if len(o.Secrets) > 0 {
+		secrets := map[string]string{}
+		for url, secret := range p.Secrets {
+			secrets[url] = secret
+		}
+		for url, secret := range o.Secrets {
+			secrets[url] = secret
+		}
+		p.Secrets = secrets
+	}
+	if len(o.Flags) > 0 {
+		flags := map[string]map[string]string{}
+		for command, values := range p.Flags {
+			flags[command] = values
+		}
+		for command, values := range o.Flags {
+			merged := map[string]string{}
+			for name, value := range flags[command] {
+				merged[name] = value
+			}
+			for name, value := range values {
+				merged[name] = value
+			}
+			flags[command] = merged
+		}
+		p.Flags = flags
+	}
+	return p
+}
+
+// Profile returns the named profile, or the default profile when name is
+// empty. With neither, an empty profile is returned.
+func (c *Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		return Profile{}, nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		var names []string
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return Profile{}, fmt.Errorf("profile %q not found, no profiles are configured", name)
+		}
+		return Profile{}, fmt.Errorf("profile %q not found, available profiles: %s", name, strings.Join(names, ", "))
+	}
+	p.Name = name
+	return p, nil
+}
+
+// Apply sets the flags of cmd that weren't given on the command line to the
+// profile's values. Flags set this way aren't marked as changed, use IsSet to
+// tell whether a flag was given either way.
+func (p Profile) Apply(cmd *cobra.Command) error {
+	values := map[string]string{
+		"hostname":            p.Hostname,
+		"token":               p.Token,
+		"app-private-key":     p.AppPrivateKey,
+		"source-hostname":     p.SourceHostname,
+		"source-token":        p.SourceToken,
+		"source-organization": p.SourceOrganization,
+	}
+	if p.AppID != 0 {
+		values["app-id"] = strconv.FormatInt(p.AppID, 10)
+	}
+	if p.InstallationID != 0 {
+		values["installation-id"] = strconv.FormatInt(p.InstallationID, 10)
+	}
+	if cmd.Name() == "list" {
+		values["format"] = p.Format
+	}
+	command := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	// Webhooks read from a file replace those of the source organization
+	if cmd.Flags().Changed("from-file") || p.Flags[command]["from-file"] != "" {
+		delete(values, "source-organization")
+	}
+	for name, value := range values {
+		if value == "" || cmd.Flags().Lookup(name) == nil {
+			continue
+		}
+		if err := p.setFlag(cmd, name, value); err != nil {
+			return err
+		}
+	}
+
+	for name, value := range p.Flags[command] {
+		if cmd.Flags().Lookup(name) == nil {
+			return fmt.Errorf("profile %s: %s has no flag --%s", p.Name, command, name)
+		}
+		if err := p.setFlag(cmd, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p Profile) setFlag(cmd *cobra.Command, name, value string) error {
+	flag := cmd.Flags().Lookup(name)
+	if flag.Changed {
+		return nil
+	}
+	if name == "token" || name == "source-token" {
+		var err error
+		if value, err = Resolve(value); err != nil {
+			return fmt.Errorf("profile %s: %s: %w", p.Name, name, err)
+		}
+	}
+	if err := flag.Value.Set(value); err != nil {
+		return fmt.Errorf("profile %s: invalid value %q for --%s: %w", p.Name, value, name, err)
+	}
+	if flag.Annotations == nil {
+		flag.Annotations = map[string][]string{}
+	}
+	flag.Annotations[profileAnnotation] = []string{p.Name}
+	// Cobra only counts a required flag as set when it is marked as changed
+	if _, required := flag.Annotations[cobra.BashCompOneRequiredFlag]; required {
+		flag.Changed = true
+	}
+	return nil
+}
+
+// profileAnnotation marks the flags set from a profile
+const profileAnnotation = "gh-organization-webhooks_profile"
+
+// IsSet reports whether the flag name of cmd was given on the command line or
+// set from the profile.
+func IsSet(cmd *cobra.Command, name string) bool {
+	flag := cmd.Flags().Lookup(name)
+	if flag == nil {
+		return false
+	}
+	_, fromProfile := flag.Annotations[profileAnnotation]
+	return flag.Changed || fromProfile
+}
+
+// Secret returns the secret configured for a webhook URL.
+func (p Profile) Secret(url string) (string, bool, error) {
+	secret, ok := p.Secrets[url]
+	if !ok {
+		return "", false, nil
+	}
+	secret, err := Resolve(secret)
+	if err != nil {
+		return "", false, fmt.Errorf("secret for %s: %w", url, err)
+	}
+	return secret, true, nil
+}
+
+// RewriteURL applies the first rewrite rule whose prefix matches url.
+func (p Profile) RewriteURL(url string) string {
+	for _, r := range p.Rewrite {
+		if r.From != "" && strings.HasPrefix(url, r.From) {
+			return r.To + strings.TrimPrefix(url, r.From)
+		}
+	}
+	return url
+}
+
+// OrganizationArg returns the organization argument at index i, falling back to
+// the profile's organization.
+func (p Profile) OrganizationArg(args []string, i int) (string, error) {
+	if i < len(args) {
+		return args[i], nil
+	}
+	if p.Organization != "" {
+		return p.Organization, nil
+	}
+	return "", errors.New("an organization must be given as an argument or set as organization in a config profile")
+}
+
+// Resolve returns value, or the environment variable NAME for a value of the
+// form env:NAME.
+func Resolve(value string) (string, error) {
+	name, ok := strings.CutPrefix(value, "env:")
+	if !ok {
+		return value, nil
+	}
+	resolved, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return resolved, nil
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the selected profile.
+func NewContext(ctx context.Context, p Profile) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the profile selected for the command, or an empty
+// profile when there is none.
+func FromContext(ctx context.Context) Profile {
+	if ctx == nil {
+		return Profile{}
+	}
+	p, _ := ctx.Value(contextKey{}).(Profile)
+	return p
+}
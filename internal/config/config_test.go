@@ -0,0 +1,280 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadMergesFiles(t *testing.T) {
+	user := writeFile(t, `
+default_profile: ghes
+profiles:
+  ghes:
+    hostname: github.example.com
+    token: env:GHES_TOKEN
+    organization: my-org
+    secrets:
+      https://ci.example.com/github: env:CI_SECRET
+    flags:
+      lint:
+        format: sarif
+`)
+	dir := writeFile(t, `
+default_profile: migration
+profiles:
+  ghes:
+    organization: other-org
+    secrets:
+      https://chat.example.com/github: chat-secret
+  migration:
+    source_hostname: github.com
+`)
+
+	c, err := Load(user, filepath.Join(t.TempDir(), "missing.yaml"), dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if c.DefaultProfile != "migration" {
+		t.Errorf("Expected the directory file's default profile, got %q", c.DefaultProfile)
+	}
+
+	p, err := c.Profile("ghes")
+	if err != nil {
+		t.Fatalf("Profile() error = %v", err)
+	}
+	if p.Name != "ghes" || p.Hostname != "github.example.com" || p.Organization != "other-org" {
+		t.Errorf("Unexpected merged profile %+v", p)
+	}
+	if len(p.Secrets) != 2 || p.Flags["lint"]["format"] != "sarif" {
+		t.Errorf("Expected secrets and flags from both files, got %+v", p)
+	}
+}
+
+func TestLoadDefaultRestrictsDirectoryFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	userFile := filepath.Join(dir, "gh-organization-webhooks", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(userFile), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(userFile, []byte("default_profile: ghes\nprofiles:\n  ghes:\n    hostname: github.example.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	allowed := "profiles:\n  ghes:\n    format: json\n    flags:\n      create:\n        fail-fast: \"true\"\n"
+	if err := os.WriteFile(DirectoryFile, []byte(allowed), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadDefault()
+	if err != nil {
+		t.Fatalf("LoadDefault() error = %v", err)
+	}
+	if p, _ := c.Profile(""); p.Hostname != "github.example.com" || p.Format != "json" {
+		t.Errorf("Unexpected merged profile %+v", p)
+	}
+	if strings.Join(c.Files, " ") != userFile+" "+DirectoryFile {
+		t.Errorf("Unexpected files %v", c.Files)
+	}
+
+	for setting, content := range map[string]string{
+		"default_profile":     "default_profile: ghes\n",
+		"hostname":            "profiles:\n  ghes:\n    hostname: evil.example.com\n",
+		"app_private_key":     "profiles:\n  ghes:\n    app_private_key: key.pem\n",
+		"secrets":             "profiles:\n  ghes:\n    secrets:\n      https://ci.example.com/github: known\n",
+		"--allowed-host":      "profiles:\n  ghes:\n    flags:\n      create:\n        allowed-host: evil.example.com\n",
+		"--force":             "profiles:\n  ghes:\n    flags:\n      create:\n        force: \"true\"\n",
+		"organization":        "profiles:\n  ghes:\n    organization: other-org\n",
+		"source_organization": "profiles:\n  ghes:\n    source_organization: other-org\n",
+		"--journal":           "profiles:\n  ghes:\n    flags:\n      create:\n        journal: /tmp/journal.jsonl\n",
+		"--state-file":        "profiles:\n  ghes:\n    flags:\n      forward:\n        state-file: state.json\n",
+	} {
+		if err := os.WriteFile(DirectoryFile, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadDefault(); err == nil || !strings.Contains(err.Error(), setting) {
+			t.Errorf("Expected the directory file's %s to be rejected, got %v", setting, err)
+		}
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := writeFile(t, "profiles:\n  ghes:\n    hostnme: github.example.com\n")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("Expected unknown field error naming the file, got %v", err)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	c := &Config{Profiles: map[string]Profile{"ghes": {Hostname: "github.example.com"}}}
+
+	if p, err := c.Profile(""); err != nil || p.Hostname != "" {
+		t.Errorf("Expected an empty profile without a default, got %+v (%v)", p, err)
+	}
+	if _, err := c.Profile("missing"); err == nil || !strings.Contains(err.Error(), "available profiles: ghes") {
+		t.Errorf("Expected unknown profile error, got %v", err)
+	}
+}
+
+func newCommand() *cobra.Command {
+	root := &cobra.Command{Use: "organization-webhooks"}
+	list := &cobra.Command{Use: "list", Run: func(*cobra.Command, []string) {}}
+	list.Flags().String("hostname", "github.com", "")
+	list.Flags().String("token", "", "")
+	list.Flags().String("format", "csv", "")
+	list.Flags().Int64("app-id", 0, "")
+	list.Flags().String("output-file", "report.csv", "")
+	list.Flags().String("policy-file", "", "")
+	_ = list.MarkFlagRequired("policy-file")
+	root.AddCommand(list)
+	return list
+}
+
+func TestApply(t *testing.T) {
+	t.Setenv("GHES_TOKEN", "token-from-env")
+	cmd := newCommand()
+	if err := cmd.ParseFlags([]string{"--format", "json"}); err != nil {
+		t.Fatal(err)
+	}
+
+	p := Profile{
+		Name:     "ghes",
+		Hostname: "github.example.com",
+		Token:    "env:GHES_TOKEN",
+		AppID:    42,
+		Format:   "yaml",
+		Flags:    map[string]map[string]string{"list": {"output-file": "reports/webhooks.csv", "policy-file": "policy.yml"}},
+	}
+	if err := p.Apply(cmd); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	want := map[string]string{
+		"hostname":    "github.example.com",
+		"token":       "token-from-env",
+		"app-id":      "42",
+		"format":      "json", // given on the command line
+		"output-file": "reports/webhooks.csv",
+	}
+	for name, value := range want {
+		if got := cmd.Flag(name).Value.String(); got != value {
+			t.Errorf("--%s = %q, want %q", name, got, value)
+		}
+	}
+	if cmd.Flags().Changed("output-file") {
+		t.Error("Expected flags set from the profile not to be marked as changed")
+	}
+	if !IsSet(cmd, "output-file") || !IsSet(cmd, "format") {
+		t.Error("Expected flags set from the profile or the command line to be set")
+	}
+	if IsSet(newCommand(), "output-file") {
+		t.Error("Expected a flag left at its default not to be set")
+	}
+	if err := cmd.ValidateRequiredFlags(); err != nil {
+		t.Errorf("Expected required flag set from the profile to count as set, got %v", err)
+	}
+}
+
+func TestApplySkipsSourceOrganizationWithFromFile(t *testing.T) {
+	root := &cobra.Command{Use: "organization-webhooks"}
+	create := &cobra.Command{Use: "create", Run: func(*cobra.Command, []string) {}}
+	create.Flags().String("source-organization", "", "")
+	create.Flags().String("from-file", "", "")
+	root.AddCommand(create)
+	if err := create.ParseFlags([]string{"--from-file", "webhooks.csv"}); err != nil {
+		t.Fatal(err)
+	}
+
+	p := Profile{Name: "migration", SourceOrganization: "source-org"}
+	if err := p.Apply(create); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got := create.Flag("source-organization").Value.String(); got != "" {
+		t.Errorf("Expected --source-organization to be left unset with --from-file, got %q", got)
+	}
+
+	create.Flag("from-file").Changed = false
+	_ = create.Flag("from-file").Value.Set("")
+	if err := p.Apply(create); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got := create.Flag("source-organization").Value.String(); got != "source-org" {
+		t.Errorf("Expected the profile's source organization without --from-file, got %q", got)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := map[string]Profile{
+		"unknown flag":  {Flags: map[string]map[string]string{"list": {"outptu-file": "x"}}},
+		"invalid value": {AppID: 0, Flags: map[string]map[string]string{"list": {"app-id": "abc"}}},
+		"unset env":     {Token: "env:GH_ORGANIZATION_WEBHOOKS_UNSET"},
+	}
+	for name, p := range tests {
+		if err := p.Apply(newCommand()); err == nil {
+			t.Errorf("%s: expected error, got nil", name)
+		}
+	}
+}
+
+func TestSecretAndRewrite(t *testing.T) {
+	t.Setenv("CI_SECRET", "from-env")
+	p := Profile{
+		Secrets: map[string]string{"https://ci.example.com/github": "env:CI_SECRET"},
+		Rewrite: []Rewrite{
+			{From: "https://jenkins.old.example.com/", To: "https://jenkins.example.com/"},
+			{From: "http://", To: "https://"},
+		},
+	}
+
+	if secret, ok, err := p.Secret("https://ci.example.com/github"); err != nil || !ok || secret != "from-env" {
+		t.Errorf("Secret() = %q, %v, %v", secret, ok, err)
+	}
+	if _, ok, _ := p.Secret("https://other.example.com/github"); ok {
+		t.Error("Expected no secret for an unconfigured URL")
+	}
+
+	for url, want := range map[string]string{
+		"https://jenkins.old.example.com/github-webhook/": "https://jenkins.example.com/github-webhook/",
+		"http://chat.example.com/hooks":                   "https://chat.example.com/hooks",
+		"https://ci.example.com/github":                   "https://ci.example.com/github",
+	} {
+		if got := p.RewriteURL(url); got != want {
+			t.Errorf("RewriteURL(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestOrganizationArg(t *testing.T) {
+	p := FromContext(NewContext(context.Background(), Profile{Organization: "my-org"}))
+
+	if owner, _ := p.OrganizationArg([]string{"given-org"}, 0); owner != "given-org" {
+		t.Errorf("Expected the argument to take precedence, got %q", owner)
+	}
+	if owner, _ := p.OrganizationArg([]string{"snapshot.json"}, 1); owner != "my-org" {
+		t.Errorf("Expected the profile's organization, got %q", owner)
+	}
+	if _, err := FromContext(context.Background()).OrganizationArg(nil, 0); err == nil {
+		t.Error("Expected error without an argument or profile organization")
+	}
+}
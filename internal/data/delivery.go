@@ -0,0 +1,137 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"regexp"
+	"sort"
+	"time"
+)
+
+var nextLinkRE = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// HookDelivery is a webhook delivery as returned by the organization hook
+// deliveries endpoints. Request and Response are only populated when a
+// single delivery is fetched.
+type HookDelivery struct {
+	ID             int64            `json:"id"`
+	GUID           string           `json:"guid"`
+	DeliveredAt    time.Time        `json:"delivered_at"`
+	Redelivery     bool             `json:"redelivery"`
+	Duration       float64          `json:"duration"`
+	Status         string           `json:"status"`
+	StatusCode     int              `json:"status_code"`
+	Event          string           `json:"event"`
+	Action         string           `json:"action"`
+	InstallationID int64            `json:"installation_id"`
+	RepositoryID   int64            `json:"repository_id"`
+	URL            string           `json:"url,omitempty"`
+	Request        DeliveryRequest  `json:"request"`
+	Response       DeliveryResponse `json:"response"`
+}
+
+type DeliveryRequest struct {
+	Headers map[string]string `json:"headers"`
+	Payload json.RawMessage   `json:"payload"`
+}
+
+type DeliveryResponse struct {
+	Headers map[string]string `json:"headers"`
+	Payload string            `json:"payload"`
+}
+
+// GetHookDelivery returns a single delivery, including its request headers and payload
+func (g *APIGetter) GetHookDelivery(ctx context.Context, owner string, hookID, deliveryID int64) ([]byte, error) {
+	url := fmt.Sprintf("orgs/%s/hooks/%d/deliveries/%d", owner, hookID, deliveryID)
+	return g.get(ctx, url)
+}
+
+// GetHookDeliveries returns one page of a hook's deliveries, newest first, and
+// the cursor for the next page. The cursor is empty on the last page.
+func (g *APIGetter) GetHookDeliveries(ctx context.Context, owner string, hookID int64, cursor string) ([]byte, string, error) {
+	path := fmt.Sprintf("orgs/%s/hooks/%d/deliveries?per_page=100", owner, hookID)
+	if cursor != "" {
+		path += "&cursor=" + url.QueryEscape(cursor)
+	}
+
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, "", newAPIError("GET", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Error closing response body: %v", err)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, nextCursor(resp.Header.Get("Link")), nil
+}
+
+// DeliveryPager returns the pages of a hook's deliveries.
+type DeliveryPager interface {
+	GetHookDeliveries(ctx context.Context, owner string, hookID int64, cursor string) ([]byte, string, error)
+}
+
+// DeliveriesAfter pages through a hook's deliveries, newest first, until it
+// reaches afterID and returns the newer deliveries sorted oldest first. At
+// most maxPages pages are read, 0 for no limit.
+func DeliveriesAfter(ctx context.Context, g DeliveryPager, owner string, hookID, afterID int64, maxPages int) ([]HookDelivery, error) {
+	var deliveries []HookDelivery
+	cursor := ""
+	for pages := 1; ; pages++ {
+		body, next, err := g.GetHookDeliveries(ctx, owner, hookID, cursor)
+		if err != nil {
+			return nil, err
+		}
+		var page []HookDelivery
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, err
+		}
+
+		reachedLast := false
+		for _, d := range page {
+			if d.ID <= afterID {
+				reachedLast = true
+				continue
+			}
+			deliveries = append(deliveries, d)
+		}
+		if reachedLast || next == "" || pages == maxPages {
+			break
+		}
+		cursor = next
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+	return deliveries, nil
+}
+
+// nextLink extracts the URL of the rel="next" page from a Link header
+func nextLink(link string) string {
+	m := nextLinkRE.FindStringSubmatch(link)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// nextCursor extracts the cursor of the rel="next" page from a Link header
+func nextCursor(link string) string {
+	m := nextLink(link)
+	if m == "" {
+		return ""
+	}
+	next, err := url.Parse(m)
+	if err != nil {
+		return ""
+	}
+	return next.Query().Get("cursor")
+}
@@ -0,0 +1,101 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestGetHookDelivery(t *testing.T) {
+	g := newTransportAPIGetter(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/orgs/test-org/hooks/1/deliveries/42" {
+			t.Errorf("Unexpected path %s", req.URL.Path)
+		}
+		return newResponse(req, 200, nil, `{"id":42,"event":"push","request":{"headers":{"X-GitHub-Event":"push"},"payload":{"ref":"main"}}}`), nil
+	})
+
+	body, err := g.GetHookDelivery(context.Background(), "test-org", 1, 42)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var delivery HookDelivery
+	if err := json.Unmarshal(body, &delivery); err != nil || delivery.ID != 42 || string(delivery.Request.Payload) != `{"ref":"main"}` {
+		t.Errorf("Unexpected delivery %+v (%v)", delivery, err)
+	}
+}
+
+func TestGetHookDeliveries(t *testing.T) {
+	g := newTransportAPIGetter(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/orgs/test-org/hooks/1/deliveries" {
+			t.Errorf("Unexpected path %s", req.URL.Path)
+		}
+		if req.URL.Query().Get("cursor") == "" {
+			header := http.Header{"Link": []string{`<https://api.github.com/organizations/1/hooks/1/deliveries?per_page=100&cursor=v1_41>; rel="next"`}}
+			return newResponse(req, 200, header, `[{"id":42}]`), nil
+		}
+		return newResponse(req, 200, nil, `[{"id":41}]`), nil
+	})
+
+	body, cursor, err := g.GetHookDeliveries(context.Background(), "test-org", 1, "")
+	if err != nil || string(body) != `[{"id":42}]` || cursor != "v1_41" {
+		t.Errorf("Unexpected first page %s, cursor %q (%v)", body, cursor, err)
+	}
+
+	body, cursor, err = g.GetHookDeliveries(context.Background(), "test-org", 1, cursor)
+	if err != nil || string(body) != `[{"id":41}]` || cursor != "" {
+		t.Errorf("Unexpected last page %s, cursor %q (%v)", body, cursor, err)
+	}
+}
+
+// pagedDeliveries serves deliveries newest first, two per page
+type pagedDeliveries struct {
+	ids   []int64
+	pages int
+}
+
+func (p *pagedDeliveries) GetHookDeliveries(ctx context.Context, owner string, hookID int64, cursor string) ([]byte, string, error) {
+	p.pages++
+	start, _ := strconv.Atoi(cursor)
+	end := min(start+2, len(p.ids))
+	var page []HookDelivery
+	for _, id := range p.ids[start:end] {
+		page = append(page, HookDelivery{ID: id})
+	}
+	next := ""
+	if end < len(p.ids) {
+		next = strconv.Itoa(end)
+	}
+	body, err := json.Marshal(page)
+	return body, next, err
+}
+
+func TestDeliveriesAfter(t *testing.T) {
+	ids := func(deliveries []HookDelivery) []int64 {
+		var ids []int64
+		for _, d := range deliveries {
+			ids = append(ids, d.ID)
+		}
+		return ids
+	}
+
+	g := &pagedDeliveries{ids: []int64{7, 6, 5, 4, 3, 2, 1}}
+	deliveries, err := DeliveriesAfter(context.Background(), g, "test-org", 1, 3, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !slices.Equal(ids(deliveries), []int64{4, 5, 6, 7}) || g.pages != 3 {
+		t.Errorf("Expected deliveries 4 to 7 from 3 pages, got %v from %d", ids(deliveries), g.pages)
+	}
+
+	g = &pagedDeliveries{ids: []int64{7, 6, 5, 4, 3, 2, 1}}
+	deliveries, err = DeliveriesAfter(context.Background(), g, "test-org", 1, 0, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !slices.Equal(ids(deliveries), []int64{6, 7}) || g.pages != 1 {
+		t.Errorf("Expected the newest page only, got %v from %d pages", ids(deliveries), g.pages)
+	}
+}
@@ -0,0 +1,205 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// Kinds of API failure that are reported with their own message and exit code.
+// Use errors.Is to test for them.
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrForbidden        = errors.New("forbidden")
+	ErrRateLimited      = errors.New("rate limited")
+	ErrValidationFailed = errors.New("validation failed")
+)
+
+// FieldError is a single field level error from the body of a 422 response
+type FieldError struct {
+	Resource string `json:"resource,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Code     string `json:"code,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+func (e FieldError) String() string {
+	if e.Code == "" || e.Code == "custom" {
+		return e.Message
+	}
+	var field string
+	switch {
+	case e.Resource != "" && e.Field != "":
+		field = e.Resource + "." + e.Field
+	case e.Field != "":
+		field = e.Field
+	default:
+		field = e.Resource
+	}
+	msg := strings.ReplaceAll(e.Code, "_", " ")
+	switch e.Code {
+	case "missing", "missing_field":
+		msg = "is missing"
+	case "invalid", "unprocessable":
+		msg = "is invalid"
+	case "already_exists":
+		msg = "already exists"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return strings.TrimSpace(field + " " + msg)
+}
+
+// APIError is a failed GitHub API request, classified by Kind
+type APIError struct {
+	// Kind is ErrNotFound, ErrForbidden, ErrRateLimited, ErrValidationFailed or nil
+	Kind       error
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+	// Errors are the field errors of a validation failure
+	Errors []FieldError
+	// RequiredScopes (any one of which is accepted) and GrantedScopes come from the
+	// X-Accepted-OAuth-Scopes and X-OAuth-Scopes headers, and are empty for
+	// fine-grained and GitHub App tokens
+	RequiredScopes []string
+	GrantedScopes  []string
+	// ResetAt is when the primary rate limit resets, if GitHub reported it
+	ResetAt time.Time
+
+	err *api.HTTPError
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s: ", e.Method, e.Path)
+	if e.Kind != nil {
+		fmt.Fprintf(&b, "%s ", e.Kind)
+	}
+	fmt.Fprintf(&b, "(HTTP %d)", e.StatusCode)
+	if e.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.Message)
+	}
+
+	switch e.Kind {
+	case ErrNotFound:
+		b.WriteString(". Check the organization name, and that the token can administer its webhooks (GitHub returns 404 rather than 403 when it cannot)")
+	case ErrForbidden:
+		if missing := e.MissingScopes(); len(missing) > 0 {
+			fmt.Fprintf(&b, ". The token is missing the %s scope", strings.Join(missing, " or "))
+			if len(e.GrantedScopes) > 0 {
+				fmt.Fprintf(&b, " (it has %s)", strings.Join(e.GrantedScopes, ", "))
+			}
+		} else {
+			b.WriteString(". Check that the token belongs to an organization owner, or that the GitHub App has the Webhooks organization permission")
+		}
+	case ErrRateLimited:
+		if !e.ResetAt.IsZero() {
+			fmt.Fprintf(&b, ". Try again after %s", e.ResetAt.Local().Format(time.Kitchen))
+		} else {
+			b.WriteString(". Try again later, or lower --write-rate")
+		}
+	case ErrValidationFailed:
+		for _, fieldErr := range e.Errors {
+			if s := fieldErr.String(); s != "" && !strings.Contains(e.Message, s) {
+				fmt.Fprintf(&b, "\n  %s", s)
+			}
+		}
+	}
+	return b.String()
+}
+
+// Unwrap returns the kind of failure and the underlying go-gh error
+func (e *APIError) Unwrap() []error {
+	var errs []error
+	if e.err != nil {
+		errs = append(errs, e.err)
+	}
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	return errs
+}
+
+// MissingScopes returns the scopes GitHub accepts for the request when the token has none of them
+func (e *APIError) MissingScopes() []string {
+	for _, accepted := range e.RequiredScopes {
+		for _, granted := range e.GrantedScopes {
+			if granted == accepted {
+				return nil
+			}
+		}
+	}
+	return e.RequiredScopes
+}
+
+// newAPIError classifies err when it is a go-gh HTTP error, and returns any other error unchanged
+func newAPIError(method string, err error) error {
+	var httpErr *api.HTTPError
+	if !errors.As(err, &httpErr) {
+		return err
+	}
+
+	apiErr := &APIError{
+		Method:         method,
+		StatusCode:     httpErr.StatusCode,
+		Message:        strings.SplitN(httpErr.Message, "\n", 2)[0],
+		RequiredScopes: splitScopes(httpErr.Headers.Get("X-Accepted-OAuth-Scopes")),
+		GrantedScopes:  splitScopes(httpErr.Headers.Get("X-OAuth-Scopes")),
+		err:            httpErr,
+	}
+	if httpErr.RequestURL != nil {
+		apiErr.Path = strings.TrimPrefix(httpErr.RequestURL.Path, "/api/v3")
+	}
+	if reset, err := strconv.ParseInt(httpErr.Headers.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		apiErr.ResetAt = time.Unix(reset, 0)
+	}
+	for _, item := range httpErr.Errors {
+		apiErr.Errors = append(apiErr.Errors, FieldError{
+			Resource: item.Resource,
+			Field:    item.Field,
+			Code:     item.Code,
+			Message:  item.Message,
+		})
+	}
+
+	switch {
+	case httpErr.StatusCode == http.StatusTooManyRequests, isRateLimited(httpErr):
+		apiErr.Kind = ErrRateLimited
+	case httpErr.StatusCode == http.StatusNotFound:
+		apiErr.Kind = ErrNotFound
+	case httpErr.StatusCode == http.StatusUnauthorized, httpErr.StatusCode == http.StatusForbidden:
+		apiErr.Kind = ErrForbidden
+	case httpErr.StatusCode == http.StatusUnprocessableEntity:
+		apiErr.Kind = ErrValidationFailed
+	}
+	return apiErr
+}
+
+func isRateLimited(err *api.HTTPError) bool {
+	if err.StatusCode != http.StatusForbidden {
+		return false
+	}
+	if err.Headers.Get("X-RateLimit-Remaining") == "0" || err.Headers.Get("Retry-After") != "" {
+		return true
+	}
+	msg := strings.ToLower(err.Message)
+	return strings.Contains(msg, "rate limit") || strings.Contains(msg, "abuse detection")
+}
+
+func splitScopes(header string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(header, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
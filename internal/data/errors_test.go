@@ -0,0 +1,122 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAPIErrorKinds(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		header   http.Header
+		body     string
+		kind     error
+		contains string
+	}{
+		{
+			name:     "not found",
+			status:   404,
+			body:     `{"message":"Not Found"}`,
+			kind:     ErrNotFound,
+			contains: "Check the organization name",
+		},
+		{
+			name:     "missing scope",
+			status:   403,
+			header:   http.Header{"X-Accepted-Oauth-Scopes": {"admin:org_hook"}, "X-Oauth-Scopes": {"repo, read:org"}},
+			body:     `{"message":"Must have admin rights to Repository."}`,
+			kind:     ErrForbidden,
+			contains: "missing the admin:org_hook scope (it has repo, read:org)",
+		},
+		{
+			name:     "forbidden",
+			status:   403,
+			body:     `{"message":"Resource not accessible by integration"}`,
+			kind:     ErrForbidden,
+			contains: "Webhooks organization permission",
+		},
+		{
+			name:     "primary rate limit",
+			status:   403,
+			header:   http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"1700000000"}},
+			body:     `{"message":"API rate limit exceeded"}`,
+			kind:     ErrRateLimited,
+			contains: "Try again after",
+		},
+		{
+			name:     "secondary rate limit",
+			status:   429,
+			body:     `{"message":"You have exceeded a secondary rate limit"}`,
+			kind:     ErrRateLimited,
+			contains: "Try again later",
+		},
+		{
+			name:     "validation failed",
+			status:   422,
+			body:     `{"message":"Validation Failed","errors":[{"resource":"Hook","code":"custom","message":"Hook already exists on this organization"},{"resource":"Hook","field":"config.url","code":"invalid"}]}`,
+			kind:     ErrValidationFailed,
+			contains: "Hook already exists on this organization",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTransportAPIGetter(t, func(req *http.Request) (*http.Response, error) {
+				return newResponse(req, tt.status, tt.header.Clone(), tt.body), nil
+			})
+
+			_, err := g.CreateOrganizationWebhook(context.Background(), "test-org", strings.NewReader(`{}`))
+			if !errors.Is(err, tt.kind) {
+				t.Fatalf("Expected %v, got %v", tt.kind, err)
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Fatalf("Expected APIError with status %d, got %v", tt.status, err)
+			}
+			if !strings.HasPrefix(err.Error(), "POST /orgs/test-org/hooks: ") {
+				t.Errorf("Expected request in message, got %q", err.Error())
+			}
+			if !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("Expected message to contain %q, got %q", tt.contains, err.Error())
+			}
+		})
+	}
+}
+
+func TestAPIErrorFieldErrors(t *testing.T) {
+	g := newTransportAPIGetter(t, func(req *http.Request) (*http.Response, error) {
+		return newResponse(req, 422, nil, `{"message":"Validation Failed","errors":[{"resource":"Hook","field":"config.url","code":"invalid"},{"resource":"Hook","field":"events","code":"missing_field"}]}`), nil
+	})
+
+	_, err := g.CreateOrganizationWebhook(context.Background(), "test-org", strings.NewReader(`{}`))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+	if len(apiErr.Errors) != 2 || apiErr.Errors[0].Field != "config.url" || apiErr.Errors[1].Code != "missing_field" {
+		t.Errorf("Unexpected field errors %+v", apiErr.Errors)
+	}
+	if !strings.Contains(err.Error(), "\n  Hook.config.url is invalid\n  Hook.events is missing") {
+		t.Errorf("Expected field errors in message, got %q", err.Error())
+	}
+}
+
+func TestAPIErrorPassesThroughOtherErrors(t *testing.T) {
+	netErr := errors.New("connection refused")
+	g := newTransportAPIGetter(t, func(req *http.Request) (*http.Response, error) {
+		return nil, netErr
+	})
+
+	_, err := g.GetOrganizationWebhooks(context.Background(), "test-org")
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		t.Errorf("Expected transport error not to be an APIError, got %v", err)
+	}
+	if !errors.Is(err, netErr) {
+		t.Errorf("Expected transport error to be returned, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package data
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// WebhookFilter selects webhooks by their configuration. Zero values match
+// every webhook.
+type WebhookFilter struct {
+	Active        *bool
+	Events        []string
+	URLMatch      *regexp.Regexp
+	ContentType   string
+	InsecureSSL   bool
+	CreatedBefore time.Time
+	UpdatedSince  time.Time
+}
+
+// Matches reports whether the webhook satisfies every criteria of the filter.
+// A webhook matches Events when it is subscribed to any of them, or to `*`.
+func (f WebhookFilter) Matches(hook Webhook) bool {
+	if f.Active != nil && hook.Active != *f.Active {
+		return false
+	}
+	if len(f.Events) > 0 && !subscribedToAny(hook.Events, f.Events) {
+		return false
+	}
+	if f.URLMatch != nil && !f.URLMatch.MatchString(hook.Config.Url) {
+		return false
+	}
+	if f.ContentType != "" && hook.Config.ContentType != f.ContentType {
+		return false
+	}
+	if f.InsecureSSL && hook.Config.InsecureSSL != "1" {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !hook.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	if !f.UpdatedSince.IsZero() && hook.UpdatedAt.Before(f.UpdatedSince) {
+		return false
+	}
+	return true
+}
+
+// FilterWebhooks returns the webhooks matching the filter, preserving their order.
+func FilterWebhooks(hooks []Webhook, f WebhookFilter) []Webhook {
+	filtered := make([]Webhook, 0, len(hooks))
+	for _, hook := range hooks {
+		if f.Matches(hook) {
+			filtered = append(filtered, hook)
+		}
+	}
+	return filtered
+}
+
+// ParseFilterTime parses a filter date given as either YYYY-MM-DD or RFC3339.
+func ParseFilterTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or RFC3339", value)
+	}
+	return t, nil
+}
+
+func subscribedToAny(subscribed []string, events []string) bool {
+	for _, s := range subscribed {
+		if s == "*" {
+			return true
+		}
+		for _, e := range events {
+			if s == e {
+				return true
+			}
+		}
+	}
+	return false
+}
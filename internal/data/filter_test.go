@@ -0,0 +1,96 @@
+package data
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func filterTestWebhooks() []Webhook {
+	return []Webhook{
+		{
+			ID:        1,
+			Active:    true,
+			Events:    []string{"push", "pull_request"},
+			Config:    Config{ContentType: "json", InsecureSSL: "0", Url: "https://ci.example.com/hook"},
+			CreatedAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:        2,
+			Active:    false,
+			Events:    []string{"*"},
+			Config:    Config{ContentType: "form", InsecureSSL: "1", Url: "http://legacy.example.org/hook"},
+			CreatedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:        3,
+			Active:    true,
+			Events:    []string{"issues"},
+			Config:    Config{ContentType: "json", InsecureSSL: "0", Url: "https://chat.example.com/hook"},
+			CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func filteredIDs(hooks []Webhook) []int {
+	ids := []int{}
+	for _, hook := range hooks {
+		ids = append(ids, hook.ID)
+	}
+	return ids
+}
+
+func TestFilterWebhooks(t *testing.T) {
+	active := true
+	inactive := false
+
+	tests := []struct {
+		name   string
+		filter WebhookFilter
+		want   []int
+	}{
+		{name: "no filter", filter: WebhookFilter{}, want: []int{1, 2, 3}},
+		{name: "active", filter: WebhookFilter{Active: &active}, want: []int{1, 3}},
+		{name: "inactive", filter: WebhookFilter{Active: &inactive}, want: []int{2}},
+		{name: "event includes wildcard", filter: WebhookFilter{Events: []string{"push"}}, want: []int{1, 2}},
+		{name: "wildcard event", filter: WebhookFilter{Events: []string{"*"}}, want: []int{2}},
+		{name: "url match", filter: WebhookFilter{URLMatch: regexp.MustCompile(`^https://.*\.example\.com`)}, want: []int{1, 3}},
+		{name: "content type", filter: WebhookFilter{ContentType: "form"}, want: []int{2}},
+		{name: "insecure ssl", filter: WebhookFilter{InsecureSSL: true}, want: []int{2}},
+		{name: "created before", filter: WebhookFilter{CreatedBefore: time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)}, want: []int{1, 2}},
+		{name: "updated since", filter: WebhookFilter{UpdatedSince: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}, want: []int{1, 3}},
+		{name: "combined", filter: WebhookFilter{Active: &active, ContentType: "json", Events: []string{"issues"}}, want: []int{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filteredIDs(FilterWebhooks(filterTestWebhooks(), tt.filter))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFilterTime(t *testing.T) {
+	if got, err := ParseFilterTime("2023-04-05"); err != nil || !got.Equal(time.Date(2023, 4, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected result %v (%v)", got, err)
+	}
+	if got, err := ParseFilterTime("2023-04-05T10:00:00Z"); err != nil || got.Hour() != 10 {
+		t.Errorf("Unexpected result %v (%v)", got, err)
+	}
+	if got, err := ParseFilterTime(""); err != nil || !got.IsZero() {
+		t.Errorf("Expected zero time, got %v (%v)", got, err)
+	}
+	if _, err := ParseFilterTime("yesterday"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
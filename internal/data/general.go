@@ -1,6 +1,8 @@
 package data
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -16,20 +18,20 @@ import (
 )
 
 type Webhook struct {
-	HookType  string    `json:"type"`
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Active    bool      `json:"active"`
-	Events    []string  `json:"events"`
-	Config    Config    `json:"config"`
-	UpdatedAt time.Time `json:"updated_at"`
-	CreatedAt time.Time `json:"created_at"`
+	HookType  string    `json:"type" yaml:"type"`
+	ID        int       `json:"id" yaml:"id"`
+	Name      string    `json:"name" yaml:"name"`
+	Active    bool      `json:"active" yaml:"active"`
+	Events    []string  `json:"events" yaml:"events"`
+	Config    Config    `json:"config" yaml:"config"`
+	UpdatedAt time.Time `json:"updated_at" yaml:"updated_at"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
 }
 type Config struct {
-	ContentType string `json:"content_type"`
-	InsecureSSL string `json:"insecure_ssl"`
-	Secret      string `json:"secret"`
-	Url         string `json:"url"`
+	ContentType string `json:"content_type" yaml:"content_type"`
+	InsecureSSL string `json:"insecure_ssl" yaml:"insecure_ssl"`
+	Secret      string `json:"secret" yaml:"secret"`
+	Url         string `json:"url" yaml:"url"`
 }
 
 type CreatedWebhook struct {
@@ -39,12 +41,33 @@ type CreatedWebhook struct {
 	Config Config   `json:"config"`
 }
 
+type Organization struct {
+	Login string `json:"login"`
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+}
+
+type Membership struct {
+	State string `json:"state"`
+	Role  string `json:"role"`
+}
+
 type Getter interface {
-	GetOrganizationWebhooks(owner string) ([]byte, error)
-	CreateWebhookList(data [][]string) []Webhook
-	CreateOrganizationWebhook(owner string, data []byte) error
+	GetOrganizationWebhooks(ctx context.Context, owner string) ([]byte, error)
+	CreateWebhookList(data [][]string) []CreatedWebhook
+	CreateOrganizationWebhook(ctx context.Context, owner string, data io.Reader) ([]byte, error)
+	DeleteOrganizationWebhook(ctx context.Context, owner string, hookID int64) error
+	UpdateOrganizationWebhook(ctx context.Context, owner string, hookID int64, data io.Reader) ([]byte, error)
+	UpdateOrganizationWebhookConfig(ctx context.Context, owner string, hookID int64, data io.Reader) ([]byte, error)
+	GetOrganization(ctx context.Context, owner string) ([]byte, error)
+	GetOrganizationMembership(ctx context.Context, owner string) ([]byte, error)
+	GetTokenScopes(ctx context.Context) ([]string, bool, error)
+	GetHookDelivery(ctx context.Context, owner string, hookID, deliveryID int64) ([]byte, error)
+	GetHookDeliveries(ctx context.Context, owner string, hookID int64, cursor string) ([]byte, string, error)
 }
 
+var _ Getter = (*APIGetter)(nil)
+
 type APIGetter struct {
 	restClient *api.RESTClient
 }
@@ -55,25 +78,11 @@ func NewAPIGetter(restClient *api.RESTClient) *APIGetter {
 	}
 }
 
-func (g *APIGetter) GetOrganizationWebhooks(owner string) ([]byte, error) {
-	url := fmt.Sprintf("orgs/%s/hooks", owner)
-
-	resp, err := g.restClient.Request("GET", url, nil)
-	if err != nil {
-		log.Printf("Body read error")
-	} else {
-		defer func() {
-			if err := resp.Body.Close(); err != nil {
-				log.Printf("Error closing response body: %v", err)
-			}
-		}()
-		responseData, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Printf("Body read error")
-		}
-		return responseData, err
-	}
-	return nil, err
+// GetOrganizationWebhooks returns all of the organization's webhooks as a
+// single JSON array, following the pages of the Link header.
+func (g *APIGetter) GetOrganizationWebhooks(ctx context.Context, owner string) ([]byte, error) {
+	url := fmt.Sprintf("orgs/%s/hooks?per_page=100", owner)
+	return g.getAllPages(ctx, url)
 }
 
 func (g *APIGetter) CreateWebhookList(data [][]string) []CreatedWebhook {
@@ -93,12 +102,13 @@ func (g *APIGetter) CreateWebhookList(data [][]string) []CreatedWebhook {
 	return webhookList
 }
 
-func (g *APIGetter) CreateOrganizationWebhook(owner string, data io.Reader) error {
+// CreateOrganizationWebhook creates a webhook and returns the created webhook
+func (g *APIGetter) CreateOrganizationWebhook(ctx context.Context, owner string, data io.Reader) ([]byte, error) {
 	url := fmt.Sprintf("orgs/%s/hooks", owner)
 
-	resp, err := g.restClient.Request("POST", url, data)
+	resp, err := g.restClient.RequestWithContext(ctx, "POST", url, data)
 	if err != nil {
-		return err
+		return nil, newAPIError("POST", err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -107,30 +117,131 @@ func (g *APIGetter) CreateOrganizationWebhook(owner string, data io.Reader) erro
 	}()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(bodyBytes))
+		return nil, newAPIError("POST", api.HandleHTTPError(resp))
 	}
 
+	return io.ReadAll(resp.Body)
+}
+
+// DeleteOrganizationWebhook deletes a webhook from the organization
+func (g *APIGetter) DeleteOrganizationWebhook(ctx context.Context, owner string, hookID int64) error {
+	url := fmt.Sprintf("orgs/%s/hooks/%d", owner, hookID)
+
+	resp, err := g.restClient.RequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return newAPIError("DELETE", err)
+	}
+	if err := resp.Body.Close(); err != nil {
+		log.Printf("Error closing response body: %v", err)
+	}
 	return nil
 }
 
-func GetSourceOrganizationWebhooks(owner string, g *APIGetter) ([]byte, error) {
-	url := fmt.Sprintf("orgs/%s/hooks", owner)
+// UpdateOrganizationWebhook updates the active state and events of a webhook
+// and returns the updated webhook
+func (g *APIGetter) UpdateOrganizationWebhook(ctx context.Context, owner string, hookID int64, data io.Reader) ([]byte, error) {
+	url := fmt.Sprintf("orgs/%s/hooks/%d", owner, hookID)
+	return g.patch(ctx, url, data)
+}
+
+// UpdateOrganizationWebhookConfig updates the configuration of a webhook. Only the
+// fields present in data are changed, so the secret is kept unless it is sent.
+func (g *APIGetter) UpdateOrganizationWebhookConfig(ctx context.Context, owner string, hookID int64, data io.Reader) ([]byte, error) {
+	url := fmt.Sprintf("orgs/%s/hooks/%d/config", owner, hookID)
+	return g.patch(ctx, url, data)
+}
+
+func GetSourceOrganizationWebhooks(ctx context.Context, owner string, g *APIGetter) ([]byte, error) {
+	url := fmt.Sprintf("orgs/%s/hooks?per_page=100", owner)
 	zap.S().Debugf("Reading in hooks from %v", url)
-	resp, err := g.restClient.Request("GET", url, nil)
+	return g.getAllPages(ctx, url)
+}
+
+func (g *APIGetter) GetOrganization(ctx context.Context, owner string) ([]byte, error) {
+	url := fmt.Sprintf("orgs/%s", owner)
+	return g.get(ctx, url)
+}
+
+// GetOrganizationMembership returns the authenticated user's membership in the organization
+func (g *APIGetter) GetOrganizationMembership(ctx context.Context, owner string) ([]byte, error) {
+	url := fmt.Sprintf("user/memberships/orgs/%s", owner)
+	return g.get(ctx, url)
+}
+
+// GetTokenScopes returns the OAuth scopes granted to the token. The boolean is false when
+// GitHub does not report scopes, as is the case for fine-grained and GitHub App tokens.
+func (g *APIGetter) GetTokenScopes(ctx context.Context) ([]string, bool, error) {
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", "rate_limit", nil)
 	if err != nil {
-		log.Printf("Body read error, %v", err)
+		return nil, false, newAPIError("GET", err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
 			log.Printf("Error closing response body: %v", err)
 		}
 	}()
-	responseData, err := io.ReadAll(resp.Body)
+
+	header, ok := resp.Header["X-Oauth-Scopes"]
+	if !ok {
+		return nil, false, nil
+	}
+	return splitScopes(strings.Join(header, ",")), true, nil
+}
+
+func (g *APIGetter) get(ctx context.Context, url string) ([]byte, error) {
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		log.Printf("Body read error, %v", err)
+		return nil, newAPIError("GET", err)
 	}
-	return responseData, err
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Error closing response body: %v", err)
+		}
+	}()
+	return io.ReadAll(resp.Body)
+}
+
+// getAllPages requests url and each rel="next" page after it, and returns the
+// elements of the JSON arrays of all pages as a single array.
+func (g *APIGetter) getAllPages(ctx context.Context, url string) ([]byte, error) {
+	var items []json.RawMessage
+	for url != "" {
+		resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, newAPIError("GET", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Error closing response body: %v", err)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var page []json.RawMessage
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("unable to parse response from %s: %w", url, err)
+		}
+		items = append(items, page...)
+		url = nextLink(resp.Header.Get("Link"))
+	}
+	if items == nil {
+		items = []json.RawMessage{}
+	}
+	return json.Marshal(items)
+}
+
+func (g *APIGetter) patch(ctx context.Context, url string, data io.Reader) ([]byte, error) {
+	resp, err := g.restClient.RequestWithContext(ctx, "PATCH", url, data)
+	if err != nil {
+		return nil, newAPIError("PATCH", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Error closing response body: %v", err)
+		}
+	}()
+	return io.ReadAll(resp.Body)
 }
 
 // The entered password will not be displayed on the screen
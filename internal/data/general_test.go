@@ -2,13 +2,17 @@ package data
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
 )
 
 func TestGetOrganizationWebhooks(t *testing.T) {
@@ -31,7 +35,7 @@ func TestGetOrganizationWebhooks(t *testing.T) {
 	mockGetter.OrganizationWebhooksData = expectedResponse
 
 	// Execute
-	response, err := mockGetter.GetOrganizationWebhooks("test-org")
+	response, err := mockGetter.GetOrganizationWebhooks(context.Background(), "test-org")
 
 	// Verify
 	if err != nil {
@@ -50,7 +54,7 @@ func TestGetOrganizationWebhooksError(t *testing.T) {
 	mockGetter.ErrorMessage = "API error"
 
 	// Execute
-	_, err := mockGetter.GetOrganizationWebhooks("test-org")
+	_, err := mockGetter.GetOrganizationWebhooks(context.Background(), "test-org")
 
 	// Verify
 	if err == nil {
@@ -99,7 +103,7 @@ func TestGetOrganizationWebhooksWithRESTClient(t *testing.T) {
 	wrapper := NewAPIGetterWithMockREST(mockClient)
 
 	// Execute
-	response, err := wrapper.GetOrganizationWebhooks("test-org")
+	response, err := wrapper.GetOrganizationWebhooks(context.Background(), "test-org")
 
 	// Verify
 	if err != nil {
@@ -121,7 +125,7 @@ func TestGetOrganizationWebhooksNetworkError(t *testing.T) {
 	wrapper := NewAPIGetterWithMockREST(mockClient)
 
 	// Execute
-	_, err := wrapper.GetOrganizationWebhooks("test-org")
+	_, err := wrapper.GetOrganizationWebhooks(context.Background(), "test-org")
 
 	// Verify
 	if err == nil {
@@ -133,6 +137,38 @@ func TestGetOrganizationWebhooksNetworkError(t *testing.T) {
 	}
 }
 
+func TestGetOrganizationWebhooksPaginated(t *testing.T) {
+	g := newTransportAPIGetter(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Query().Get("page") == "" {
+			if req.URL.Path != "/orgs/test-org/hooks" || req.URL.Query().Get("per_page") != "100" {
+				t.Errorf("Unexpected request %s", req.URL)
+			}
+			header := http.Header{"Link": []string{`<https://api.github.com/organizations/1/hooks?per_page=100&page=2>; rel="next", <https://api.github.com/organizations/1/hooks?per_page=100&page=2>; rel="last"`}}
+			return newResponse(req, 200, header, `[{"id":1},{"id":2}]`), nil
+		}
+		return newResponse(req, 200, nil, `[{"id":3}]`), nil
+	})
+
+	body, err := g.GetOrganizationWebhooks(context.Background(), "test-org")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(body) != `[{"id":1},{"id":2},{"id":3}]` {
+		t.Errorf("Expected the webhooks of both pages, got %s", body)
+	}
+}
+
+func TestGetOrganizationWebhooksEmpty(t *testing.T) {
+	g := newTransportAPIGetter(t, func(req *http.Request) (*http.Response, error) {
+		return newResponse(req, 200, nil, `[]`), nil
+	})
+
+	body, err := g.GetOrganizationWebhooks(context.Background(), "test-org")
+	if err != nil || string(body) != `[]` {
+		t.Errorf("Expected an empty array, got %s (%v)", body, err)
+	}
+}
+
 func TestCreateWebhookList(t *testing.T) {
 	// Setup
 	mockGetter := NewMockAPIGetter()
@@ -198,7 +234,7 @@ func TestCreateOrganizationWebhook(t *testing.T) {
 	reader := bytes.NewReader(webhookData)
 
 	// Execute
-	err := mockGetter.CreateOrganizationWebhook("test-org", reader)
+	_, err := mockGetter.CreateOrganizationWebhook(context.Background(), "test-org", reader)
 
 	// Verify
 	if err != nil {
@@ -215,7 +251,7 @@ func TestCreateOrganizationWebhookError(t *testing.T) {
 	reader := bytes.NewReader([]byte(`{}`))
 
 	// Execute
-	err := mockGetter.CreateOrganizationWebhook("test-org", reader)
+	_, err := mockGetter.CreateOrganizationWebhook(context.Background(), "test-org", reader)
 
 	// Verify
 	if err == nil {
@@ -274,7 +310,7 @@ func TestCreateOrganizationWebhookWithRESTClient(t *testing.T) {
 	reader := bytes.NewReader(webhookData)
 
 	// Execute
-	err := wrapper.CreateOrganizationWebhook("test-org", reader)
+	_, err := wrapper.CreateOrganizationWebhook(context.Background(), "test-org", reader)
 
 	// Verify
 	if err != nil {
@@ -314,7 +350,7 @@ func TestGetSourceOrganizationWebhooksMultipleEntries(t *testing.T) {
 	mockGetter.ResponseBody = mockResponse
 
 	// Execute
-	response, err := mockGetter.GetSourceOrganizationWebhooks("source-org")
+	response, err := mockGetter.GetSourceOrganizationWebhooks(context.Background(), "source-org")
 
 	// Verify
 	if err != nil {
@@ -460,7 +496,7 @@ func TestCreateOrganizationWebhookBodyReadError(t *testing.T) {
 	_, _ = io.ReadAll(reader)
 
 	// Now attempt to use it in the API call - this should trigger an EOF error
-	err := wrapper.CreateOrganizationWebhook("test-org", reader)
+	_, err := wrapper.CreateOrganizationWebhook(context.Background(), "test-org", reader)
 
 	// Verify
 	if err == nil {
@@ -480,7 +516,7 @@ func TestGetSourceOrganizationWebhooksWithEmptyResponse(t *testing.T) {
 	// No need to set any fields - by default it will return an empty array
 
 	// Execute
-	response, err := mockGetter.GetSourceOrganizationWebhooks("source-org")
+	response, err := mockGetter.GetSourceOrganizationWebhooks(context.Background(), "source-org")
 
 	// Verify
 	if err != nil {
@@ -524,7 +560,7 @@ func TestCreateOrganizationWebhookWithNetworkError(t *testing.T) {
 	reader := bytes.NewReader(webhookData)
 
 	// Execute
-	err := wrapper.CreateOrganizationWebhook("test-org", reader)
+	_, err := wrapper.CreateOrganizationWebhook(context.Background(), "test-org", reader)
 
 	// Verify
 	if err == nil {
@@ -549,7 +585,7 @@ func TestGetOrganizationWebhooksWithMalformedResponse(t *testing.T) {
 	wrapper := NewAPIGetterWithMockREST(mockClient)
 
 	// Execute
-	response, err := wrapper.GetOrganizationWebhooks("test-org")
+	response, err := wrapper.GetOrganizationWebhooks(context.Background(), "test-org")
 
 	// Verify
 	// The implementation might just return the raw bytes without parsing
@@ -654,3 +690,161 @@ func TestCreateWebhookListWithNoEvents(t *testing.T) {
 		t.Errorf("Expected empty events array or single empty string, got %v", webhooks[0].Events)
 	}
 }
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newTransportAPIGetter returns an APIGetter backed by a real REST client whose
+// requests are answered by the given function
+func newTransportAPIGetter(t *testing.T, fn roundTripFunc) *APIGetter {
+	t.Helper()
+	restClient, err := api.NewRESTClient(api.ClientOptions{
+		Host:      "github.com",
+		AuthToken: "test-token",
+		Transport: fn,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create REST client: %v", err)
+	}
+	return NewAPIGetter(restClient)
+}
+
+func newResponse(req *http.Request, status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Type", "application/json")
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestGetTokenScopes(t *testing.T) {
+	g := newTransportAPIGetter(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/rate_limit" {
+			t.Errorf("Unexpected path %s", req.URL.Path)
+		}
+		return newResponse(req, 200, http.Header{"X-Oauth-Scopes": []string{"admin:org_hook, repo"}}, `{}`), nil
+	})
+
+	scopes, known, err := g.GetTokenScopes(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !known || len(scopes) != 2 || scopes[0] != "admin:org_hook" || scopes[1] != "repo" {
+		t.Errorf("Unexpected scopes %v (known %v)", scopes, known)
+	}
+}
+
+func TestGetTokenScopesNotReported(t *testing.T) {
+	g := newTransportAPIGetter(t, func(req *http.Request) (*http.Response, error) {
+		return newResponse(req, 200, nil, `{}`), nil
+	})
+
+	_, known, err := g.GetTokenScopes(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if known {
+		t.Error("Expected scopes to be unknown when header is missing")
+	}
+}
+
+func TestGetOrganizationNotFound(t *testing.T) {
+	g := newTransportAPIGetter(t, func(req *http.Request) (*http.Response, error) {
+		return newResponse(req, 404, nil, `{"message":"Not Found"}`), nil
+	})
+
+	_, err := g.GetOrganization(context.Background(), "missing-org")
+	var httpErr *api.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != 404 {
+		t.Errorf("Expected 404 HTTPError, got %v", err)
+	}
+}
+
+func TestGetOrganizationMembership(t *testing.T) {
+	g := newTransportAPIGetter(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/user/memberships/orgs/test-org" {
+			t.Errorf("Unexpected path %s", req.URL.Path)
+		}
+		return newResponse(req, 200, nil, `{"state":"active","role":"admin"}`), nil
+	})
+
+	body, err := g.GetOrganizationMembership(context.Background(), "test-org")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var membership Membership
+	if err := json.Unmarshal(body, &membership); err != nil || membership.Role != "admin" {
+		t.Errorf("Unexpected membership %+v (%v)", membership, err)
+	}
+}
+
+func TestCreateOrganizationWebhookReturnsWebhook(t *testing.T) {
+	g := newTransportAPIGetter(t, func(req *http.Request) (*http.Response, error) {
+		return newResponse(req, 201, nil, `{"id":12345678,"url":"https://api.github.com/orgs/test-org/hooks/12345678"}`), nil
+	})
+
+	body, err := g.CreateOrganizationWebhook(context.Background(), "test-org", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var created struct {
+		ID  int64  `json:"id"`
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil || created.ID != 12345678 {
+		t.Errorf("Unexpected created webhook %s (%v)", body, err)
+	}
+}
+
+func TestDeleteOrganizationWebhook(t *testing.T) {
+	g := newTransportAPIGetter(t, func(req *http.Request) (*http.Response, error) {
+		if req.Method != "DELETE" || req.URL.Path != "/orgs/test-org/hooks/42" {
+			t.Errorf("Unexpected request %s %s", req.Method, req.URL.Path)
+		}
+		return newResponse(req, 204, nil, ``), nil
+	})
+
+	if err := g.DeleteOrganizationWebhook(context.Background(), "test-org", 42); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestDeleteOrganizationWebhookNotFound(t *testing.T) {
+	g := newTransportAPIGetter(t, func(req *http.Request) (*http.Response, error) {
+		return newResponse(req, 404, nil, `{"message":"Not Found"}`), nil
+	})
+
+	err := g.DeleteOrganizationWebhook(context.Background(), "test-org", 42)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestUpdateOrganizationWebhook(t *testing.T) {
+	paths := map[string]bool{}
+	g := newTransportAPIGetter(t, func(req *http.Request) (*http.Response, error) {
+		if req.Method != "PATCH" {
+			t.Errorf("Unexpected method %s", req.Method)
+		}
+		paths[req.URL.Path] = true
+		return newResponse(req, 200, nil, `{"id":42}`), nil
+	})
+
+	if _, err := g.UpdateOrganizationWebhook(context.Background(), "test-org", 42, strings.NewReader(`{"active":true}`)); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if _, err := g.UpdateOrganizationWebhookConfig(context.Background(), "test-org", 42, strings.NewReader(`{"insecure_ssl":"0"}`)); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if !paths["/orgs/test-org/hooks/42"] || !paths["/orgs/test-org/hooks/42/config"] {
+		t.Errorf("Unexpected requests %v", paths)
+	}
+}
@@ -2,6 +2,7 @@ package data
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"strings"
@@ -15,6 +16,18 @@ type MockAPIGetter struct {
 	CreatedWebhooks          []CreatedWebhook
 	ShouldReturnResponse     bool
 	ResponseBody             []byte
+	OrganizationData         []byte
+	MembershipData           []byte
+	TokenScopes              []string
+	TokenScopesKnown         bool
+	HookDeliveryData         []byte
+	HookDeliveriesData       []byte
+	CreatedWebhookData       []byte
+	DeletedHookIDs           []int64
+	UpdatedWebhooks          map[int64][]byte
+	UpdatedWebhookConfigs    map[int64][]byte
+	// MethodErrors makes individual methods fail, keyed by method name
+	MethodErrors map[string]error
 }
 
 // NewMockAPIGetter creates a new mock API getter
@@ -23,14 +36,17 @@ func NewMockAPIGetter() *MockAPIGetter {
 }
 
 // GetOrganizationWebhooks mocks retrieving organization webhooks
-func (m *MockAPIGetter) GetOrganizationWebhooks(owner string) ([]byte, error) {
+func (m *MockAPIGetter) GetOrganizationWebhooks(ctx context.Context, owner string) ([]byte, error) {
 	if m.ShouldReturnError {
 		return nil, fmt.Errorf(m.ErrorMessage)
 	}
+	if err := m.MethodErrors["GetOrganizationWebhooks"]; err != nil {
+		return nil, err
+	}
 	return m.OrganizationWebhooksData, nil
 }
 
-func (m *MockAPIGetter) GetSourceOrganizationWebhooks(owner string) ([]byte, error) {
+func (m *MockAPIGetter) GetSourceOrganizationWebhooks(ctx context.Context, owner string) ([]byte, error) {
 	if m.ShouldReturnError {
 		return nil, fmt.Errorf(m.ErrorMessage)
 	}
@@ -74,13 +90,99 @@ func (m *MockAPIGetter) CreateWebhookList(data [][]string) []CreatedWebhook {
 	return webhooks
 }
 
-func (m *MockAPIGetter) CreateOrganizationWebhook(owner string, data io.Reader) error {
+func (m *MockAPIGetter) CreateOrganizationWebhook(ctx context.Context, owner string, data io.Reader) ([]byte, error) {
+	if err := m.MethodErrors["CreateOrganizationWebhook"]; err != nil {
+		return nil, err
+	}
 	if m.ShouldReturnError {
-		return fmt.Errorf(m.ErrorMessage)
+		return nil, fmt.Errorf(m.ErrorMessage)
+	}
+	return m.CreatedWebhookData, nil
+}
+
+// DeleteOrganizationWebhook mocks deleting a webhook, recording its ID
+func (m *MockAPIGetter) DeleteOrganizationWebhook(ctx context.Context, owner string, hookID int64) error {
+	if err := m.MethodErrors["DeleteOrganizationWebhook"]; err != nil {
+		return err
 	}
+	m.DeletedHookIDs = append(m.DeletedHookIDs, hookID)
 	return nil
 }
 
+// UpdateOrganizationWebhook mocks updating a webhook, recording the request body
+func (m *MockAPIGetter) UpdateOrganizationWebhook(ctx context.Context, owner string, hookID int64, data io.Reader) ([]byte, error) {
+	if err := m.MethodErrors["UpdateOrganizationWebhook"]; err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
+	if m.UpdatedWebhooks == nil {
+		m.UpdatedWebhooks = map[int64][]byte{}
+	}
+	m.UpdatedWebhooks[hookID] = body
+	return body, nil
+}
+
+// UpdateOrganizationWebhookConfig mocks updating a webhook's configuration, recording the request body
+func (m *MockAPIGetter) UpdateOrganizationWebhookConfig(ctx context.Context, owner string, hookID int64, data io.Reader) ([]byte, error) {
+	if err := m.MethodErrors["UpdateOrganizationWebhookConfig"]; err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
+	if m.UpdatedWebhookConfigs == nil {
+		m.UpdatedWebhookConfigs = map[int64][]byte{}
+	}
+	m.UpdatedWebhookConfigs[hookID] = body
+	return body, nil
+}
+
+// GetOrganization mocks retrieving an organization
+func (m *MockAPIGetter) GetOrganization(ctx context.Context, owner string) ([]byte, error) {
+	if err := m.MethodErrors["GetOrganization"]; err != nil {
+		return nil, err
+	}
+	return m.OrganizationData, nil
+}
+
+// GetOrganizationMembership mocks retrieving the authenticated user's organization membership
+func (m *MockAPIGetter) GetOrganizationMembership(ctx context.Context, owner string) ([]byte, error) {
+	if err := m.MethodErrors["GetOrganizationMembership"]; err != nil {
+		return nil, err
+	}
+	return m.MembershipData, nil
+}
+
+// GetTokenScopes mocks retrieving the scopes granted to the token
+func (m *MockAPIGetter) GetTokenScopes(ctx context.Context) ([]string, bool, error) {
+	if err := m.MethodErrors["GetTokenScopes"]; err != nil {
+		return nil, false, err
+	}
+	return m.TokenScopes, m.TokenScopesKnown, nil
+}
+
+// GetHookDelivery mocks retrieving a single hook delivery
+func (m *MockAPIGetter) GetHookDelivery(ctx context.Context, owner string, hookID, deliveryID int64) ([]byte, error) {
+	if err := m.MethodErrors["GetHookDelivery"]; err != nil {
+		return nil, err
+	}
+	return m.HookDeliveryData, nil
+}
+
+// GetHookDeliveries mocks retrieving a single page of hook deliveries
+func (m *MockAPIGetter) GetHookDeliveries(ctx context.Context, owner string, hookID int64, cursor string) ([]byte, string, error) {
+	if err := m.MethodErrors["GetHookDeliveries"]; err != nil {
+		return nil, "", err
+	}
+	return m.HookDeliveriesData, "", nil
+}
+
+var _ Getter = (*MockAPIGetter)(nil)
+
 // TestAPIGetterWrapper wraps a MockRESTClient with the APIGetter interface
 type TestAPIGetterWrapper struct {
 	MockClient *MockRESTClient
@@ -93,9 +195,9 @@ func NewAPIGetterWithMockREST(client *MockRESTClient) *TestAPIGetterWrapper {
 }
 
 // GetOrganizationWebhooks implementation for TestAPIGetterWrapper
-func (t *TestAPIGetterWrapper) GetOrganizationWebhooks(owner string) ([]byte, error) {
+func (t *TestAPIGetterWrapper) GetOrganizationWebhooks(ctx context.Context, owner string) ([]byte, error) {
 	url := fmt.Sprintf("orgs/%s/hooks", owner)
-	resp, err := t.MockClient.Request("GET", url, nil)
+	resp, err := t.MockClient.RequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -112,7 +214,7 @@ func (t *TestAPIGetterWrapper) CreateWebhookList(data [][]string) []CreatedWebho
 	return webhookList
 }
 
-func (t *TestAPIGetterWrapper) CreateOrganizationWebhook(owner string, data io.Reader) error {
+func (t *TestAPIGetterWrapper) CreateOrganizationWebhook(ctx context.Context, owner string, data io.Reader) ([]byte, error) {
 	url := fmt.Sprintf("orgs/%s/hooks", owner)
 
 	// Check if we can read from the data reader before making the request
@@ -122,14 +224,14 @@ func (t *TestAPIGetterWrapper) CreateOrganizationWebhook(owner string, data io.R
 		// If it's a bytes.Reader, we can check its size
 		if br, ok := data.(*bytes.Reader); ok {
 			if br.Len() == 0 {
-				return fmt.Errorf("empty or already consumed request body")
+				return nil, fmt.Errorf("empty or already consumed request body")
 			}
 		}
 	}
 
-	resp, err := t.MockClient.Request("POST", url, data)
+	resp, err := t.MockClient.RequestWithContext(ctx, "POST", url, data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -142,8 +244,8 @@ func (t *TestAPIGetterWrapper) CreateOrganizationWebhook(owner string, data io.R
 	// Check for non-successful status codes
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(bodyBytes))
 	}
 
-	return nil
+	return io.ReadAll(resp.Body)
 }
@@ -0,0 +1,120 @@
+// Package journal records the webhook writes made by a run in a JSON lines
+// file, so that the run can be rolled back.
+package journal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Actions recorded in a journal.
+const (
+	ActionCreate = "create"
+	ActionDelete = "delete"
+)
+
+// Entry is a line of the journal describing one write.
+type Entry struct {
+	Time          time.Time `json:"time"`
+	Action        string    `json:"action"`
+	Hostname      string    `json:"hostname"`
+	Organization  string    `json:"organization"`
+	HookID        int64     `json:"hook_id"`
+	URL           string    `json:"url,omitempty"`
+	PayloadSHA256 string    `json:"payload_sha256,omitempty"`
+}
+
+// Journal is a journal file opened for appending.
+type Journal struct {
+	Path string
+	f    *os.File
+}
+
+// Open opens or creates the journal at path. Entries are appended, so a
+// journal can be shared by several runs.
+func Open(path string) (*Journal, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &Journal{Path: path, f: f}, nil
+}
+
+// Record appends the entry and syncs it to disk, so the write is recorded
+// even if the run is killed straight after.
+func (j *Journal) Record(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := j.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return j.f.Sync()
+}
+
+// Close closes the journal.
+func (j *Journal) Close() error {
+	return j.f.Close()
+}
+
+// Read returns the entries of the journal at path.
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint:errcheck
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Outstanding returns the created hooks that have not since been deleted,
+// newest first, which is the order to roll them back in.
+func Outstanding(entries []Entry) []Entry {
+	deleted := map[string]bool{}
+	for _, e := range entries {
+		if e.Action == ActionDelete {
+			deleted[hookKey(e)] = true
+		}
+	}
+
+	var outstanding []Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Action == ActionCreate && !deleted[hookKey(e)] {
+			outstanding = append(outstanding, e)
+		}
+	}
+	return outstanding
+}
+
+// PayloadHash returns the hex SHA-256 of a request payload.
+func PayloadHash(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func hookKey(e Entry) string {
+	return fmt.Sprintf("%s/%s/%d", e.Hostname, e.Organization, e.HookID)
+}
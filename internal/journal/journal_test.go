@@ -0,0 +1,82 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	j, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for _, e := range []Entry{
+		{Action: ActionCreate, Hostname: "github.com", Organization: "target-org", HookID: 1, PayloadSHA256: PayloadHash([]byte(`{}`))},
+		{Action: ActionCreate, Hostname: "github.com", Organization: "target-org", HookID: 2},
+		{Action: ActionCreate, Hostname: "github.com", Organization: "target-org", HookID: 3},
+		{Action: ActionDelete, Hostname: "github.com", Organization: "target-org", HookID: 3},
+	} {
+		if err := j.Record(e); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected journal to be private, got %v", info.Mode().Perm())
+	}
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(entries) != 4 || entries[0].Time.IsZero() {
+		t.Fatalf("Unexpected entries %+v", entries)
+	}
+	if entries[0].PayloadSHA256 != "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a" {
+		t.Errorf("Unexpected payload hash %s", entries[0].PayloadSHA256)
+	}
+
+	outstanding := Outstanding(entries)
+	if len(outstanding) != 2 || outstanding[0].HookID != 2 || outstanding[1].HookID != 1 {
+		t.Errorf("Expected hooks 2 and 1 to be outstanding, got %+v", outstanding)
+	}
+}
+
+func TestJournalAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	for id := int64(1); id <= 2; id++ {
+		j, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		if err := j.Record(Entry{Action: ActionCreate, Organization: "target-org", HookID: id}); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		_ = j.Close()
+	}
+
+	entries, err := Read(path)
+	if err != nil || len(entries) != 2 {
+		t.Errorf("Expected both runs to be recorded, got %+v (%v)", entries, err)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	if err := os.WriteFile(path, []byte("{}\nnot json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path); err == nil {
+		t.Error("Expected error for invalid journal, got nil")
+	}
+}
@@ -0,0 +1,254 @@
+package lint
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+)
+
+// Level is the severity of a finding, using the SARIF level names.
+type Level string
+
+const (
+	LevelError   Level = "error"
+	LevelWarning Level = "warning"
+	LevelNote    Level = "note"
+)
+
+var levelRank = map[Level]int{
+	LevelNote:    1,
+	LevelWarning: 2,
+	LevelError:   3,
+}
+
+// ParseLevel validates a level name, where `none` disables failing on findings.
+func ParseLevel(value string) (Level, error) {
+	level := Level(strings.ToLower(value))
+	if _, ok := levelRank[level]; ok || level == "none" {
+		return level, nil
+	}
+	return "", fmt.Errorf("invalid level %q, expected one of: error, warning, note, none", value)
+}
+
+// AtLeast reports whether the level is as severe as the threshold.
+func (l Level) AtLeast(threshold Level) bool {
+	rank, ok := levelRank[threshold]
+	return ok && levelRank[l] >= rank
+}
+
+// Rule is a security check evaluated against every webhook.
+type Rule struct {
+	ID          string
+	Name        string
+	Description string
+	Help        string
+	Level       Level
+	check       func(hook data.Webhook) (string, bool)
+}
+
+// Finding is a rule violated by a webhook.
+type Finding struct {
+	RuleID       string `json:"rule_id"`
+	RuleName     string `json:"rule_name"`
+	Level        Level  `json:"level"`
+	Message      string `json:"message"`
+	Organization string `json:"organization"`
+	HookID       int    `json:"hook_id"`
+	URL          string `json:"url"`
+}
+
+// Rules are the built-in webhook security rules.
+var Rules = []Rule{
+	{
+		ID:          "WH001",
+		Name:        "insecure-ssl",
+		Description: "Webhook does not verify SSL certificates",
+		Help:        "Set insecure_ssl to 0 so payloads are only delivered to endpoints with a valid certificate.",
+		Level:       LevelError,
+		check: func(hook data.Webhook) (string, bool) {
+			return "SSL certificate verification is disabled (insecure_ssl=1)", hook.Config.InsecureSSL == "1"
+		},
+	},
+	{
+		ID:          "WH002",
+		Name:        "non-https-url",
+		Description: "Webhook delivers payloads over an unencrypted connection",
+		Help:        "Use an https:// URL so payloads and signatures are not sent in clear text.",
+		Level:       LevelError,
+		check: func(hook data.Webhook) (string, bool) {
+			u, err := url.Parse(hook.Config.Url)
+			if err != nil {
+				return fmt.Sprintf("URL could not be parsed: %v", err), true
+			}
+			return fmt.Sprintf("URL uses the %s scheme instead of https", u.Scheme), !strings.EqualFold(u.Scheme, "https")
+		},
+	},
+	{
+		ID:          "WH003",
+		Name:        "missing-secret",
+		Description: "Webhook has no secret configured",
+		Help:        "Configure a secret so the receiver can verify the X-Hub-Signature-256 header of each delivery.",
+		Level:       LevelWarning,
+		check: func(hook data.Webhook) (string, bool) {
+			return "no secret is configured, so deliveries cannot be verified", hook.Config.Secret == ""
+		},
+	},
+	{
+		ID:          "WH004",
+		Name:        "wildcard-events",
+		Description: "Webhook is subscribed to all events",
+		Help:        "Subscribe only to the events the receiver needs, to limit the data sent to it.",
+		Level:       LevelWarning,
+		check: func(hook data.Webhook) (string, bool) {
+			for _, event := range hook.Events {
+				if event == "*" {
+					return "webhook is subscribed to all events (*)", true
+				}
+			}
+			return "", false
+		},
+	},
+	{
+		ID:          "WH005",
+		Name:        "credentials-in-url",
+		Description: "Webhook URL embeds credentials or tokens",
+		Help:        "Remove credentials from the URL and authenticate deliveries with the webhook secret instead.",
+		Level:       LevelError,
+		check:       checkCredentials,
+	},
+	{
+		ID:          "WH006",
+		Name:        "inactive-webhook",
+		Description: "Webhook is inactive",
+		Help:        "Delete webhooks that are no longer used so stale endpoints do not receive data if re-enabled.",
+		Level:       LevelNote,
+		check: func(hook data.Webhook) (string, bool) {
+			return "webhook is inactive but still configured", !hook.Active
+		},
+	},
+	{
+		ID:          "WH007",
+		Name:        "private-network-target",
+		Description: "Webhook targets a private or internal address",
+		Help:        "Verify that deliveries to internal networks are intended; GitHub.com cannot reach private addresses.",
+		Level:       LevelWarning,
+		check:       checkPrivateTarget,
+	},
+}
+
+// Lint evaluates every rule against the organization's webhooks.
+func Lint(owner string, hooks []data.Webhook) []Finding {
+	findings := []Finding{}
+	for _, hook := range hooks {
+		for _, rule := range Rules {
+			message, violated := rule.check(hook)
+			if !violated {
+				continue
+			}
+			findings = append(findings, Finding{
+				RuleID:       rule.ID,
+				RuleName:     rule.Name,
+				Level:        rule.Level,
+				Message:      message,
+				Organization: owner,
+				HookID:       hook.ID,
+				URL:          RedactURL(hook.Config.Url),
+			})
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return levelRank[findings[i].Level] > levelRank[findings[j].Level]
+	})
+	return findings
+}
+
+// Exceeds reports whether any finding is at least as severe as the threshold.
+func Exceeds(findings []Finding, threshold Level) bool {
+	for _, f := range findings {
+		if f.Level.AtLeast(threshold) {
+			return true
+		}
+	}
+	return false
+}
+
+var sensitiveParam = regexp.MustCompile(`(?i)^(.*[_-])?(token|secret|passw(or)?d|pwd|apikey|key|auth|credentials?|sig|signature)$`)
+
+var tokenValue = regexp.MustCompile(`^(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,}|xox[abpr]-[A-Za-z0-9-]+)$`)
+
+func checkCredentials(hook data.Webhook) (string, bool) {
+	u, err := url.Parse(hook.Config.Url)
+	if err != nil {
+		return "", false
+	}
+	if u.User != nil {
+		return "URL contains user information", true
+	}
+	var params []string
+	for name, values := range u.Query() {
+		for _, value := range values {
+			if sensitiveParam.MatchString(name) || tokenValue.MatchString(value) {
+				params = append(params, name)
+				break
+			}
+		}
+	}
+	if len(params) > 0 {
+		sort.Strings(params)
+		return fmt.Sprintf("URL query contains credentials in: %s", strings.Join(params, ", ")), true
+	}
+	return "", false
+}
+
+var cgnat = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func checkPrivateTarget(hook data.Webhook) (string, bool) {
+	u, err := url.Parse(hook.Config.Url)
+	if err != nil {
+		return "", false
+	}
+	host := strings.ToLower(u.Hostname())
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() || cgnat.Contains(ip) {
+			return fmt.Sprintf("URL targets the private address %s", host), true
+		}
+		return "", false
+	}
+	for _, suffix := range []string{"localhost", ".local", ".internal", ".localdomain", ".lan", ".intranet"} {
+		if host == strings.TrimPrefix(suffix, ".") || strings.HasSuffix(host, suffix) {
+			return fmt.Sprintf("URL targets the internal host %s", host), true
+		}
+	}
+	return "", false
+}
+
+// RedactURL masks user information and sensitive query values so findings
+// can be shared without leaking credentials.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if u.User != nil {
+		u.User = url.User("redacted")
+	}
+	query := u.Query()
+	redacted := false
+	for name, values := range query {
+		for i, value := range values {
+			if sensitiveParam.MatchString(name) || tokenValue.MatchString(value) {
+				values[i] = "redacted"
+				redacted = true
+			}
+		}
+	}
+	if redacted {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,186 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Formats are the supported lint output formats.
+var Formats = []string{"text", "json", "sarif"}
+
+// Write writes the findings in the named format.
+func Write(w io.Writer, format string, findings []Finding) error {
+	switch strings.ToLower(format) {
+	case "text":
+		return WriteText(w, findings)
+	case "json":
+		return WriteJSON(w, findings)
+	case "sarif":
+		return WriteSARIF(w, findings)
+	default:
+		return fmt.Errorf("unsupported format %q, expected one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// WriteText writes one finding per line in a human-readable table.
+func WriteText(w io.Writer, findings []Finding) error {
+	if len(findings) == 0 {
+		_, err := fmt.Fprintln(w, "No findings.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, f := range findings {
+		location := f.Organization
+		if f.HookID != 0 {
+			location = fmt.Sprintf("%s/%d", f.Organization, f.HookID)
+		}
+		rule := f.RuleID
+		if f.RuleName != f.RuleID {
+			rule = f.RuleID + " " + f.RuleName
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", strings.ToUpper(string(f.Level)), rule, location, f.URL, f.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d finding(s)\n", len(findings))
+	return err
+}
+
+// WriteJSON writes the findings as a JSON array.
+func WriteJSON(w io.Writer, findings []Finding) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(findings)
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	Name                 string            `json:"name"`
+	ShortDescription     sarifMessage      `json:"shortDescription"`
+	Help                 sarifMessage      `json:"help"`
+	DefaultConfiguration sarifRuleConfig   `json:"defaultConfiguration"`
+	Properties           map[string]string `json:"properties,omitempty"`
+}
+
+type sarifRuleConfig struct {
+	Level Level `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               Level             `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// WriteSARIF writes the findings as a SARIF 2.1.0 log that can be uploaded to
+// code scanning. Each webhook is reported at the location orgs/<org>/hooks/<id>.
+func WriteSARIF(w io.Writer, findings []Finding) error {
+	driver := sarifDriver{
+		Name:           "gh-organization-webhooks",
+		InformationURI: "https://github.com/katiem0/gh-organization-webhooks",
+	}
+	ruleIndex := map[string]int{}
+	for i, rule := range Rules {
+		ruleIndex[rule.ID] = i
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			Name:                 rule.Name,
+			ShortDescription:     sarifMessage{Text: rule.Description},
+			Help:                 sarifMessage{Text: rule.Help},
+			DefaultConfiguration: sarifRuleConfig{Level: rule.Level},
+			Properties:           map[string]string{"category": "webhook-security"},
+		})
+	}
+
+	results := []sarifResult{}
+	for _, f := range findings {
+		location := fmt.Sprintf("orgs/%s/hooks/%d", f.Organization, f.HookID)
+		results = append(results, sarifResult{
+			RuleID:    f.RuleID,
+			RuleIndex: ruleIndex[f.RuleID],
+			Level:     f.Level,
+			Message:   sarifMessage{Text: fmt.Sprintf("%s: %s (%s)", f.RuleName, f.Message, f.URL)},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: location},
+					Region:           sarifRegion{StartLine: 1},
+				},
+				LogicalLocations: []sarifLogicalLocation{{
+					Name:               fmt.Sprintf("%d", f.HookID),
+					FullyQualifiedName: location,
+					Kind:               "resource",
+				}},
+			}},
+			PartialFingerprints: map[string]string{
+				"webhookFinding/v1": fmt.Sprintf("%s/%d/%s", f.Organization, f.HookID, f.RuleID),
+			},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
@@ -0,0 +1,75 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testFindings() []Finding {
+	return []Finding{{
+		RuleID:       "WH001",
+		RuleName:     "insecure-ssl",
+		Level:        LevelError,
+		Message:      "SSL certificate verification is disabled (insecure_ssl=1)",
+		Organization: "test-org",
+		HookID:       42,
+		URL:          "https://hooks.example.com",
+	}}
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "text", testFindings()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "test-org/42") || !strings.Contains(buf.String(), "1 finding(s)") {
+		t.Errorf("Unexpected text output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := Write(&buf, "text", nil); err != nil || !strings.Contains(buf.String(), "No findings.") {
+		t.Errorf("Unexpected text output %q (%v)", buf.String(), err)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "json", testFindings()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var findings []Finding
+	if err := json.Unmarshal(buf.Bytes(), &findings); err != nil || len(findings) != 1 || findings[0].HookID != 42 {
+		t.Errorf("Unexpected JSON output %s (%v)", buf.String(), err)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "sarif", testFindings()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Failed to parse SARIF: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Unexpected SARIF log %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(Rules) {
+		t.Errorf("Expected %d rules, got %d", len(Rules), len(run.Tool.Driver.Rules))
+	}
+	if len(run.Results) != 1 || run.Results[0].RuleIndex != 0 || run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI != "orgs/test-org/hooks/42" {
+		t.Errorf("Unexpected SARIF results %+v", run.Results)
+	}
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "xml", testFindings()); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
@@ -0,0 +1,247 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/katiem0/gh-organization-webhooks/pkg/signature"
+)
+
+// Config describes a load test run. The run stops after Requests deliveries
+// or after Duration, whichever comes first; zero disables either limit.
+type Config struct {
+	URL          string
+	Event        string
+	Organization string
+	Secret       []byte
+	ContentType  string
+	Rate         float64
+	Concurrency  int
+	Requests     int
+	Duration     time.Duration
+	Client       *http.Client
+}
+
+// Result summarizes a load test run.
+type Result struct {
+	Sent        int
+	Succeeded   int
+	Failed      int
+	StatusCodes map[int]int
+	Errors      map[string]int
+	Latencies   []time.Duration
+	Elapsed     time.Duration
+}
+
+type outcome struct {
+	status  int
+	err     error
+	latency time.Duration
+}
+
+// Run sends synthetic deliveries to the configured URL until a limit is
+// reached or ctx is done.
+func Run(ctx context.Context, cfg Config) (*Result, error) {
+	if cfg.Rate <= 0 {
+		return nil, errors.New("rate must be greater than zero")
+	}
+	if cfg.Concurrency < 1 {
+		return nil, errors.New("concurrency must be at least 1")
+	}
+	if cfg.Requests <= 0 && cfg.Duration <= 0 {
+		return nil, errors.New("a number of requests or a duration is required")
+	}
+	if _, err := Payload(cfg.Event, cfg.Organization, 0, time.Now()); err != nil {
+		return nil, err
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	jobs := make(chan int)
+	outcomes := make(chan outcome)
+	var wg sync.WaitGroup
+	for range cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seq := range jobs {
+				outcomes <- send(ctx, cfg, seq)
+			}
+		}()
+	}
+
+	start := time.Now()
+	go func() {
+		defer close(jobs)
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.Rate))
+		defer ticker.Stop()
+		for seq := 1; cfg.Requests <= 0 || seq <= cfg.Requests; seq++ {
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- seq:
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	result := &Result{StatusCodes: map[int]int{}, Errors: map[string]int{}}
+	for o := range outcomes {
+		// Requests cut short by the end of the run are not counted
+		if o.err != nil && ctx.Err() != nil && errors.Is(o.err, ctx.Err()) {
+			continue
+		}
+		result.Sent++
+		result.Latencies = append(result.Latencies, o.latency)
+		switch {
+		case o.err != nil:
+			result.Failed++
+			result.Errors[errorKind(o.err)]++
+		case o.status >= 200 && o.status < 300:
+			result.Succeeded++
+			result.StatusCodes[o.status]++
+		default:
+			result.Failed++
+			result.StatusCodes[o.status]++
+		}
+	}
+	result.Elapsed = time.Since(start)
+	sort.Slice(result.Latencies, func(i, j int) bool { return result.Latencies[i] < result.Latencies[j] })
+	return result, nil
+}
+
+func send(ctx context.Context, cfg Config, seq int) outcome {
+	body, err := Payload(cfg.Event, cfg.Organization, seq, time.Now())
+	if err != nil {
+		return outcome{err: err}
+	}
+	contentType := "application/json"
+	if cfg.ContentType == "form" {
+		contentType = "application/x-www-form-urlencoded"
+		body = []byte("payload=" + url.QueryEscape(string(body)))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return outcome{err: err}
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "GitHub-Hookshot/loadtest")
+	req.Header.Set("X-GitHub-Event", cfg.Event)
+	req.Header.Set("X-GitHub-Delivery", newGUID())
+	req.Header.Set("X-GitHub-Hook-Installation-Target-Type", "organization")
+	if len(cfg.Secret) > 0 {
+		signature.SetHeaders(req.Header, cfg.Secret, body)
+	}
+
+	start := time.Now()
+	resp, err := cfg.Client.Do(req)
+	if err != nil {
+		return outcome{err: err, latency: time.Since(start)}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return outcome{status: resp.StatusCode, latency: time.Since(start)}
+}
+
+// errorKind groups transport errors so the summary is not one line per request
+func errorKind(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	if errors.Is(err, context.DeadlineExceeded) || strings.Contains(err.Error(), "Client.Timeout") {
+		return "timeout"
+	}
+	return err.Error()
+}
+
+// Percentile returns the latency at percentile p (0-100) using the nearest
+// rank method.
+func (r *Result) Percentile(p float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	rank := int(float64(len(r.Latencies))*p/100+0.5) - 1
+	rank = max(0, min(rank, len(r.Latencies)-1))
+	return r.Latencies[rank]
+}
+
+// ErrorRate returns the percentage of failed deliveries.
+func (r *Result) ErrorRate() float64 {
+	if r.Sent == 0 {
+		return 0
+	}
+	return float64(r.Failed) * 100 / float64(r.Sent)
+}
+
+// Write prints a summary of the run.
+func (r *Result) Write(w io.Writer, cfg Config) error {
+	rate := 0.0
+	if r.Elapsed > 0 {
+		rate = float64(r.Sent) / r.Elapsed.Seconds()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Sent %d %s deliveries to %s in %s (%.1f req/s)\n", r.Sent, cfg.Event, cfg.URL, r.Elapsed.Round(time.Millisecond), rate)
+	fmt.Fprintf(&b, "Succeeded: %d\n", r.Succeeded)
+	fmt.Fprintf(&b, "Failed:    %d (%.2f%% error rate)\n", r.Failed, r.ErrorRate())
+
+	if len(r.StatusCodes) > 0 {
+		var codes []int
+		for code := range r.StatusCodes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		var parts []string
+		for _, code := range codes {
+			parts = append(parts, fmt.Sprintf("%d x%d", code, r.StatusCodes[code]))
+		}
+		fmt.Fprintf(&b, "Status codes: %s\n", strings.Join(parts, ", "))
+	}
+	if len(r.Errors) > 0 {
+		var kinds []string
+		for kind := range r.Errors {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		fmt.Fprintln(&b, "Errors:")
+		for _, kind := range kinds {
+			fmt.Fprintf(&b, "  %s x%d\n", kind, r.Errors[kind])
+		}
+	}
+	if len(r.Latencies) > 0 {
+		fmt.Fprintf(&b, "Latency: min %s, p50 %s, p90 %s, p95 %s, p99 %s, max %s\n",
+			r.Latencies[0].Round(time.Microsecond),
+			r.Percentile(50).Round(time.Microsecond),
+			r.Percentile(90).Round(time.Microsecond),
+			r.Percentile(95).Round(time.Microsecond),
+			r.Percentile(99).Round(time.Microsecond),
+			r.Latencies[len(r.Latencies)-1].Round(time.Microsecond))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,135 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-organization-webhooks/pkg/signature"
+)
+
+func TestPayload(t *testing.T) {
+	for _, event := range Events() {
+		raw, err := Payload(event, "test-org", 3, time.Now())
+		if err != nil {
+			t.Fatalf("Payload(%s) error = %v", event, err)
+		}
+		var payload map[string]any
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			t.Fatalf("Payload(%s) is not JSON: %v", event, err)
+		}
+		org, _ := payload["organization"].(map[string]any)
+		if org["login"] != "test-org" {
+			t.Errorf("Payload(%s) organization = %v", event, payload["organization"])
+		}
+	}
+
+	first, _ := Payload("push", "test-org", 1, time.Now())
+	second, _ := Payload("push", "test-org", 2, time.Now())
+	if bytes.Equal(first, second) {
+		t.Error("Expected consecutive payloads to differ")
+	}
+
+	if _, err := Payload("unknown", "test-org", 1, time.Now()); err == nil {
+		t.Error("Expected error for unsupported event")
+	}
+}
+
+func TestRun(t *testing.T) {
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := signature.VerifyHeaders([]byte("s3cret"), body, r.Header); err != nil {
+			t.Errorf("Delivery was not signed: %v", err)
+		}
+		if r.Header.Get("X-GitHub-Event") != "issues" || r.Header.Get("X-GitHub-Delivery") == "" {
+			t.Errorf("Unexpected headers %v", r.Header)
+		}
+		// Every fourth delivery fails
+		if count.Add(1)%4 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	cfg := Config{
+		URL:          server.URL,
+		Event:        "issues",
+		Organization: "test-org",
+		Secret:       []byte("s3cret"),
+		Rate:         1000,
+		Concurrency:  4,
+		Requests:     20,
+		Client:       server.Client(),
+	}
+	result, err := Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Sent != 20 || result.Succeeded != 15 || result.Failed != 5 || result.StatusCodes[503] != 5 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if result.ErrorRate() != 25 {
+		t.Errorf("Expected 25%% error rate, got %.2f", result.ErrorRate())
+	}
+	if len(result.Latencies) != 20 || result.Percentile(50) > result.Percentile(99) {
+		t.Errorf("Unexpected latencies %v", result.Latencies)
+	}
+
+	var buf bytes.Buffer
+	if err := result.Write(&buf, cfg); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Sent 20 issues deliveries", "Failed:    5 (25.00% error rate)", "200 x15, 503 x5", "p95"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected summary to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestRunTransportErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	result, err := Run(context.Background(), Config{URL: url, Event: "ping", Rate: 100, Concurrency: 1, Requests: 3})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Failed != 3 || len(result.Errors) != 1 {
+		t.Errorf("Expected 3 grouped transport errors, got %+v", result)
+	}
+}
+
+func TestRunValidation(t *testing.T) {
+	tests := []Config{
+		{Event: "push", Rate: 0, Concurrency: 1, Requests: 1},
+		{Event: "push", Rate: 1, Concurrency: 0, Requests: 1},
+		{Event: "push", Rate: 1, Concurrency: 1},
+		{Event: "unknown", Rate: 1, Concurrency: 1, Requests: 1},
+	}
+	for _, cfg := range tests {
+		if _, err := Run(context.Background(), cfg); err == nil {
+			t.Errorf("Expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	r := &Result{}
+	for i := 1; i <= 100; i++ {
+		r.Latencies = append(r.Latencies, time.Duration(i)*time.Millisecond)
+	}
+	for p, want := range map[float64]time.Duration{50: 50 * time.Millisecond, 99: 99 * time.Millisecond, 100: 100 * time.Millisecond} {
+		if got := r.Percentile(p); got != want {
+			t.Errorf("Percentile(%v) = %s, want %s", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// Package loadtest generates synthetic webhook deliveries and sends them to an
+// endpoint at a fixed rate to measure how it copes with an organization's
+// event volume.
+package loadtest
+
+import (
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // only used to fake commit SHAs
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type payloadFunc func(org string, seq int, now time.Time) map[string]any
+
+var payloads = map[string]payloadFunc{
+	"ping":          pingPayload,
+	"push":          pushPayload,
+	"pull_request":  pullRequestPayload,
+	"issues":        issuesPayload,
+	"issue_comment": issueCommentPayload,
+	"workflow_run":  workflowRunPayload,
+	"release":       releasePayload,
+}
+
+// Events returns the event types synthetic payloads can be generated for.
+func Events() []string {
+	var events []string
+	for event := range payloads {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	return events
+}
+
+// Payload returns a synthetic payload for the event. seq varies identifiers
+// so that consecutive payloads are distinct.
+func Payload(event, org string, seq int, now time.Time) ([]byte, error) {
+	fn, ok := payloads[event]
+	if !ok {
+		return nil, fmt.Errorf("unsupported event %q, expected one of %v", event, Events())
+	}
+	return json.Marshal(fn(org, seq, now))
+}
+
+// newGUID returns a random version 4 UUID as used for X-GitHub-Delivery.
+func newGUID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// sha returns a stable, commit-like SHA for the sequence number
+func sha(seq int, salt string) string {
+	return fmt.Sprintf("%x", sha1.Sum([]byte(fmt.Sprintf("%s-%d", salt, seq)))) //nolint:gosec // not used for security
+}
+
+func organization(org string) map[string]any {
+	return map[string]any{
+		"login":      org,
+		"id":         1000,
+		"url":        fmt.Sprintf("https://api.github.com/orgs/%s", org),
+		"hooks_url":  fmt.Sprintf("https://api.github.com/orgs/%s/hooks", org),
+		"avatar_url": "https://avatars.githubusercontent.com/u/1000?v=4",
+	}
+}
+
+func repository(org string, seq int) map[string]any {
+	name := fmt.Sprintf("service-%d", seq%25)
+	return map[string]any{
+		"id":             2000 + seq%25,
+		"name":           name,
+		"full_name":      org + "/" + name,
+		"private":        true,
+		"owner":          map[string]any{"login": org, "id": 1000, "type": "Organization"},
+		"html_url":       fmt.Sprintf("https://github.com/%s/%s", org, name),
+		"default_branch": "main",
+		"visibility":     "private",
+	}
+}
+
+func sender(seq int) map[string]any {
+	login := fmt.Sprintf("octocat-%d", seq%50)
+	return map[string]any{
+		"login":    login,
+		"id":       3000 + seq%50,
+		"type":     "User",
+		"html_url": "https://github.com/" + login,
+	}
+}
+
+func common(org string, seq int, payload map[string]any) map[string]any {
+	payload["organization"] = organization(org)
+	payload["repository"] = repository(org, seq)
+	payload["sender"] = sender(seq)
+	return payload
+}
+
+func pingPayload(org string, seq int, now time.Time) map[string]any {
+	return map[string]any{
+		"zen":          "Keep it logically awesome.",
+		"hook_id":      seq,
+		"hook":         map[string]any{"type": "Organization", "id": seq, "active": true, "events": []string{"*"}},
+		"organization": organization(org),
+		"sender":       sender(seq),
+	}
+}
+
+func pushPayload(org string, seq int, now time.Time) map[string]any {
+	before, after := sha(seq, "before"), sha(seq, "after")
+	commit := map[string]any{
+		"id":        after,
+		"message":   fmt.Sprintf("Update service configuration (#%d)", seq),
+		"timestamp": now.Format(time.RFC3339),
+		"author":    map[string]any{"name": "Octocat", "email": "octocat@example.com"},
+		"added":     []string{},
+		"removed":   []string{},
+		"modified":  []string{"config/service.yml"},
+	}
+	return common(org, seq, map[string]any{
+		"ref":         "refs/heads/main",
+		"before":      before,
+		"after":       after,
+		"created":     false,
+		"deleted":     false,
+		"forced":      false,
+		"commits":     []any{commit},
+		"head_commit": commit,
+		"pusher":      map[string]any{"name": "octocat", "email": "octocat@example.com"},
+	})
+}
+
+func pullRequestPayload(org string, seq int, now time.Time) map[string]any {
+	return common(org, seq, map[string]any{
+		"action": "opened",
+		"number": seq,
+		"pull_request": map[string]any{
+			"id":         4000 + seq,
+			"number":     seq,
+			"state":      "open",
+			"title":      fmt.Sprintf("Synthetic pull request %d", seq),
+			"body":       "Generated by gh organization-webhooks loadtest",
+			"created_at": now.Format(time.RFC3339),
+			"updated_at": now.Format(time.RFC3339),
+			"draft":      false,
+			"merged":     false,
+			"head":       map[string]any{"ref": fmt.Sprintf("feature-%d", seq), "sha": sha(seq, "head")},
+			"base":       map[string]any{"ref": "main", "sha": sha(seq, "base")},
+			"commits":    1,
+			"additions":  10,
+			"deletions":  2,
+		},
+	})
+}
+
+func issuesPayload(org string, seq int, now time.Time) map[string]any {
+	return common(org, seq, map[string]any{
+		"action": "opened",
+		"issue":  issue(seq, now),
+	})
+}
+
+func issueCommentPayload(org string, seq int, now time.Time) map[string]any {
+	return common(org, seq, map[string]any{
+		"action": "created",
+		"issue":  issue(seq, now),
+		"comment": map[string]any{
+			"id":         5000 + seq,
+			"body":       "Synthetic comment generated by gh organization-webhooks loadtest",
+			"created_at": now.Format(time.RFC3339),
+			"updated_at": now.Format(time.RFC3339),
+			"user":       sender(seq),
+		},
+	})
+}
+
+func issue(seq int, now time.Time) map[string]any {
+	return map[string]any{
+		"id":         6000 + seq,
+		"number":     seq,
+		"title":      fmt.Sprintf("Synthetic issue %d", seq),
+		"state":      "open",
+		"labels":     []any{map[string]any{"name": "bug"}},
+		"created_at": now.Format(time.RFC3339),
+		"updated_at": now.Format(time.RFC3339),
+		"user":       sender(seq),
+	}
+}
+
+func workflowRunPayload(org string, seq int, now time.Time) map[string]any {
+	return common(org, seq, map[string]any{
+		"action": "completed",
+		"workflow_run": map[string]any{
+			"id":          7000 + seq,
+			"name":        "CI",
+			"head_branch": "main",
+			"head_sha":    sha(seq, "head"),
+			"run_number":  seq,
+			"event":       "push",
+			"status":      "completed",
+			"conclusion":  "success",
+			"created_at":  now.Add(-2 * time.Minute).Format(time.RFC3339),
+			"updated_at":  now.Format(time.RFC3339),
+		},
+		"workflow": map[string]any{"id": 8000, "name": "CI", "path": ".github/workflows/ci.yml"},
+	})
+}
+
+func releasePayload(org string, seq int, now time.Time) map[string]any {
+	tag := fmt.Sprintf("v1.%d.0", seq)
+	return common(org, seq, map[string]any{
+		"action": "published",
+		"release": map[string]any{
+			"id":           9000 + seq,
+			"tag_name":     tag,
+			"name":         tag,
+			"draft":        false,
+			"prerelease":   false,
+			"created_at":   now.Format(time.RFC3339),
+			"published_at": now.Format(time.RFC3339),
+			"author":       sender(seq),
+		},
+	})
+}
@@ -0,0 +1,60 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+)
+
+// CSVHeader is the header row of CSV reports, which is also the format
+// accepted by `create --from-file`.
+var CSVHeader = []string{
+	"Type",
+	"ID",
+	"Name",
+	"Active",
+	"Events",
+	"Config_ContentType",
+	"Config_InsecureSSL",
+	"Config_Secret",
+	"Config_URL",
+	"Updated_At",
+	"Created_At",
+}
+
+type csvWriter struct{}
+
+func (csvWriter) Write(w io.Writer, owner string, hooks []data.Webhook) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(CSVHeader); err != nil {
+		return err
+	}
+	for _, webhook := range hooks {
+		if err := csvWriter.Write(CSVRecord(webhook)); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// CSVRecord returns the CSV row for a webhook, with events delimited by `;`.
+func CSVRecord(webhook data.Webhook) []string {
+	return []string{
+		webhook.HookType,
+		strconv.Itoa(webhook.ID),
+		webhook.Name,
+		strconv.FormatBool(webhook.Active),
+		strings.Join(webhook.Events, ";"),
+		webhook.Config.ContentType,
+		webhook.Config.InsecureSSL,
+		webhook.Config.Secret,
+		webhook.Config.Url,
+		webhook.UpdatedAt.Format(time.RFC3339),
+		webhook.CreatedAt.Format(time.RFC3339),
+	}
+}
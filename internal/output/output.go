@@ -0,0 +1,57 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+)
+
+// Writer writes a report of an organization's webhooks in a specific format.
+type Writer interface {
+	Write(w io.Writer, owner string, hooks []data.Webhook) error
+}
+
+var writers = map[string]Writer{
+	"csv":      csvWriter{},
+	"json":     jsonWriter{},
+	"yaml":     yamlWriter{},
+	"ndjson":   ndjsonWriter{},
+	"markdown": markdownWriter{},
+	"html":     htmlWriter{},
+}
+
+var extensions = map[string]string{
+	"yaml":     "yml",
+	"markdown": "md",
+}
+
+// NewWriter returns the writer for the named format.
+func NewWriter(format string) (Writer, error) {
+	w, ok := writers[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported format %q, expected one of: %s", format, strings.Join(Formats(), ", "))
+	}
+	return w, nil
+}
+
+// Formats returns the names of the supported formats.
+func Formats() []string {
+	formats := make([]string, 0, len(writers))
+	for format := range writers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Extension returns the file extension used for reports in the format.
+func Extension(format string) string {
+	format = strings.ToLower(format)
+	if ext, ok := extensions[format]; ok {
+		return ext
+	}
+	return format
+}
@@ -0,0 +1,133 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"gopkg.in/yaml.v3"
+)
+
+func testWebhooks() []data.Webhook {
+	return []data.Webhook{
+		{
+			HookType:  "Organization",
+			ID:        123,
+			Name:      "web",
+			Active:    true,
+			Events:    []string{"push", "pull_request"},
+			Config:    data.Config{ContentType: "json", InsecureSSL: "0", Secret: "********", Url: "https://example.com/webhook"},
+			CreatedAt: time.Date(2023, 1, 2, 3, 4, 5, 123456789, time.UTC),
+			UpdatedAt: time.Date(2023, 2, 3, 4, 5, 6, 0, time.UTC),
+		},
+		{
+			HookType: "Organization",
+			ID:       456,
+			Name:     "web",
+			Active:   false,
+			Events:   []string{"*"},
+			Config:   data.Config{ContentType: "form", InsecureSSL: "1", Url: "http://example.org/hook"},
+		},
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	for _, format := range []string{"csv", "json", "yaml", "ndjson", "JSON"} {
+		if _, err := NewWriter(format); err != nil {
+			t.Errorf("NewWriter(%s) returned error %v", format, err)
+		}
+	}
+	if _, err := NewWriter("xml"); err == nil {
+		t.Error("Expected error for unsupported format, got nil")
+	}
+}
+
+func TestExtension(t *testing.T) {
+	if Extension("yaml") != "yml" || Extension("ndjson") != "ndjson" || Extension("csv") != "csv" {
+		t.Error("Unexpected file extension")
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (csvWriter{}).Write(&buf, "test-org", testWebhooks()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 rows, got %d", len(records))
+	}
+	if records[0][0] != "Type" || records[1][4] != "push;pull_request" || records[1][10] != "2023-01-02T03:04:05Z" {
+		t.Errorf("Unexpected CSV rows: %v", records)
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (jsonWriter{}).Write(&buf, "test-org", testWebhooks()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var hooks []data.Webhook
+	if err := json.Unmarshal(buf.Bytes(), &hooks); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if len(hooks) != 2 || len(hooks[0].Events) != 2 {
+		t.Errorf("Unexpected webhooks: %+v", hooks)
+	}
+	if !hooks[0].CreatedAt.Equal(testWebhooks()[0].CreatedAt) {
+		t.Errorf("Expected full timestamp precision, got %v", hooks[0].CreatedAt)
+	}
+
+	buf.Reset()
+	if err := (jsonWriter{}).Write(&buf, "test-org", nil); err != nil || strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("Expected empty array, got %q (%v)", buf.String(), err)
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (ndjsonWriter{}).Write(&buf, "test-org", testWebhooks()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	lines := 0
+	for scanner.Scan() {
+		var hook data.Webhook
+		if err := json.Unmarshal(scanner.Bytes(), &hook); err != nil {
+			t.Errorf("Line %d is not valid JSON: %v", lines, err)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("Expected 2 lines, got %d", lines)
+	}
+}
+
+func TestYAMLWriter(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (yamlWriter{}).Write(&buf, "test-org", testWebhooks()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "content_type: json") {
+		t.Errorf("Expected snake case keys, got:\n%s", buf.String())
+	}
+
+	var hooks []data.Webhook
+	if err := yaml.Unmarshal(buf.Bytes(), &hooks); err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	if len(hooks) != 2 || hooks[1].Events[0] != "*" || !hooks[0].CreatedAt.Equal(testWebhooks()[0].CreatedAt) {
+		t.Errorf("Unexpected webhooks: %+v", hooks)
+	}
+}
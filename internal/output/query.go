@@ -0,0 +1,75 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cli/go-gh/v2/pkg/jq"
+	"github.com/cli/go-gh/v2/pkg/template"
+	"github.com/cli/go-gh/v2/pkg/term"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+)
+
+type templateWriter struct {
+	text string
+}
+
+// NewTemplateWriter returns a writer executing a Go template against the JSON
+// encoded webhooks, with the helper functions of `gh api --template`.
+func NewTemplateWriter(text string) (Writer, error) {
+	if err := template.New(io.Discard, 0, false).Parse(text); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return templateWriter{text: text}, nil
+}
+
+func (t templateWriter) Write(w io.Writer, owner string, hooks []data.Webhook) error {
+	if hooks == nil {
+		hooks = []data.Webhook{}
+	}
+	b, err := json.Marshal(hooks)
+	if err != nil {
+		return err
+	}
+
+	// Like gh, tables fit the terminal and colors are used when writing to it
+	width, colorEnabled := 80, false
+	if terminal := term.FromEnv(); w == os.Stdout && terminal.IsTerminalOutput() {
+		if termWidth, _, err := terminal.Size(); err == nil {
+			width = termWidth
+		}
+		colorEnabled = terminal.IsColorEnabled()
+	}
+	tmpl := template.New(w, width, colorEnabled)
+	if err := tmpl.Parse(t.text); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	if err := tmpl.Execute(bytes.NewReader(b)); err != nil {
+		return err
+	}
+	return tmpl.Flush()
+}
+
+type jqWriter struct {
+	expr string
+}
+
+// NewJQWriter returns a writer selecting values from the JSON encoded webhooks
+// with a jq expression.
+func NewJQWriter(expr string) Writer {
+	return jqWriter{expr: expr}
+}
+
+func (j jqWriter) Write(w io.Writer, owner string, hooks []data.Webhook) error {
+	if hooks == nil {
+		hooks = []data.Webhook{}
+	}
+	b, err := json.Marshal(hooks)
+	if err != nil {
+		return err
+	}
+	return jq.Evaluate(bytes.NewReader(b), w, j.expr)
+}
@@ -0,0 +1,70 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTemplateWriter(t *testing.T) {
+	writer, err := NewTemplateWriter(`{{range .}}{{.id}} {{.config.url}} {{join ";" .events}} {{timefmt "2006-01-02" .created_at}}{{"\n"}}{{end}}`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writer.Write(&buf, "test-org", testWebhooks()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := "123 https://example.com/webhook push;pull_request 2023-01-02\n456 http://example.org/hook * 0001-01-01\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestTemplateWriterTable(t *testing.T) {
+	writer, err := NewTemplateWriter(`{{range .}}{{tablerow .id .config.url (join "," .events)}}{{end}}{{tablerender}}{{join ";" (pluck "name" .)}}`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writer.Write(&buf, "test-org", testWebhooks()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := "123  https://example.com/webhook  push,pull_request\n456  http://example.org/hook      *\nweb;web"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestTemplateWriterInvalid(t *testing.T) {
+	if _, err := NewTemplateWriter(`{{range .}`); err == nil {
+		t.Error("Expected error for invalid template, got nil")
+	}
+}
+
+func TestJQWriter(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewJQWriter(`.[] | select(.config.insecure_ssl == "1") | .config.url`).Write(&buf, "test-org", testWebhooks()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if buf.String() != "http://example.org/hook\n" {
+		t.Errorf("Unexpected jq output %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := NewJQWriter(`.[0].events`).Write(&buf, "test-org", testWebhooks()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if buf.String() != "[\"push\",\"pull_request\"]\n" {
+		t.Errorf("Unexpected jq output %q", buf.String())
+	}
+}
+
+func TestJQWriterInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewJQWriter(`.[`).Write(&buf, "test-org", testWebhooks()); err == nil {
+		t.Error("Expected error for invalid expression, got nil")
+	}
+}
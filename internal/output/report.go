@@ -0,0 +1,152 @@
+package output
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+)
+
+type markdownWriter struct{}
+
+func (markdownWriter) Write(w io.Writer, owner string, hooks []data.Webhook) error {
+	summary := Summarize(hooks)
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Organization Webhook Report\n\n")
+	fmt.Fprintf(&b, "Generated %s\n\n", time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "## %s\n\n", owner)
+	fmt.Fprintf(&b, "| Summary | Count |\n|:--------|------:|\n")
+	for _, row := range summaryRows(summary) {
+		fmt.Fprintf(&b, "| %s | %d |\n", row.Label, row.Count)
+	}
+	fmt.Fprintf(&b, "\n")
+
+	if len(hooks) == 0 {
+		fmt.Fprintf(&b, "No webhooks found.\n")
+	} else {
+		fmt.Fprintf(&b, "| ID | URL | Active | Events | Content Type | Insecure SSL | Secret | Updated At | Created At |\n")
+		fmt.Fprintf(&b, "|---:|:----|:-------|:-------|:-------------|:-------------|:-------|:-----------|:-----------|\n")
+		for _, hook := range hooks {
+			fmt.Fprintf(&b, "| %d | %s | %t | %s | %s | %s | %s | %s | %s |\n",
+				hook.ID,
+				markdownEscape(hook.Config.Url),
+				hook.Active,
+				markdownEscape(strings.Join(hook.Events, ", ")),
+				markdownEscape(hook.Config.ContentType),
+				insecureSSLLabel(hook.Config.InsecureSSL),
+				secretLabel(hook.Config.Secret),
+				hook.UpdatedAt.Format(time.RFC3339),
+				hook.CreatedAt.Format(time.RFC3339),
+			)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type htmlWriter struct{}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"join":        strings.Join,
+	"insecureSSL": insecureSSLLabel,
+	"secret":      secretLabel,
+	"timestamp":   func(t time.Time) string { return t.Format(time.RFC3339) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Organization Webhook Report - {{.Owner}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem; color: #1f2328; }
+table { border-collapse: collapse; margin-bottom: 1.5rem; }
+th, td { border: 1px solid #d0d7de; padding: 6px 12px; text-align: left; }
+th { background: #f6f8fa; }
+.warning { color: #9a6700; font-weight: 600; }
+</style>
+</head>
+<body>
+<h1>Organization Webhook Report</h1>
+<p>Generated {{.Generated}}</p>
+<h2>{{.Owner}}</h2>
+<table>
+<tr><th>Summary</th><th>Count</th></tr>
+{{- range .Summary}}
+<tr><td>{{.Label}}</td><td>{{.Count}}</td></tr>
+{{- end}}
+</table>
+{{- if .Hooks}}
+<table>
+<tr><th>ID</th><th>URL</th><th>Active</th><th>Events</th><th>Content Type</th><th>Insecure SSL</th><th>Secret</th><th>Updated At</th><th>Created At</th></tr>
+{{- range .Hooks}}
+<tr>
+<td>{{.ID}}</td>
+<td>{{.Config.Url}}</td>
+<td>{{.Active}}</td>
+<td>{{join .Events ", "}}</td>
+<td>{{.Config.ContentType}}</td>
+<td{{if eq .Config.InsecureSSL "1"}} class="warning"{{end}}>{{insecureSSL .Config.InsecureSSL}}</td>
+<td{{if not .Config.Secret}} class="warning"{{end}}>{{secret .Config.Secret}}</td>
+<td>{{timestamp .UpdatedAt}}</td>
+<td>{{timestamp .CreatedAt}}</td>
+</tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No webhooks found.</p>
+{{- end}}
+</body>
+</html>
+`))
+
+func (htmlWriter) Write(w io.Writer, owner string, hooks []data.Webhook) error {
+	return htmlReport.Execute(w, struct {
+		Owner     string
+		Generated string
+		Summary   []summaryRow
+		Hooks     []data.Webhook
+	}{
+		Owner:     owner,
+		Generated: time.Now().UTC().Format(time.RFC3339),
+		Summary:   summaryRows(Summarize(hooks)),
+		Hooks:     hooks,
+	})
+}
+
+type summaryRow struct {
+	Label string
+	Count int
+}
+
+func summaryRows(summary Summary) []summaryRow {
+	return []summaryRow{
+		{"Total webhooks", summary.Total},
+		{"Active", summary.Active},
+		{"Inactive", summary.Inactive},
+		{"Insecure SSL", summary.InsecureSSL},
+		{"Missing secret", summary.MissingSecret},
+		{"Wildcard events", summary.WildcardEvents},
+	}
+}
+
+func insecureSSLLabel(value string) string {
+	if value == "1" {
+		return "Yes"
+	}
+	return "No"
+}
+
+func secretLabel(secret string) string {
+	if secret == "" {
+		return "Missing"
+	}
+	return "Configured"
+}
+
+func markdownEscape(value string) string {
+	return strings.ReplaceAll(value, "|", `\|`)
+}
@@ -0,0 +1,62 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+)
+
+func TestSummarize(t *testing.T) {
+	summary := Summarize(testWebhooks())
+
+	want := Summary{Total: 2, Active: 1, Inactive: 1, InsecureSSL: 1, MissingSecret: 1, WildcardEvents: 1}
+	if summary != want {
+		t.Errorf("Expected %+v, got %+v", want, summary)
+	}
+}
+
+func TestMarkdownWriter(t *testing.T) {
+	hooks := testWebhooks()
+	hooks[0].Config.Url = "https://example.com/hook?a=1|2"
+
+	var buf bytes.Buffer
+	if err := (markdownWriter{}).Write(&buf, "test-org", hooks); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	report := buf.String()
+	for _, want := range []string{"## test-org", "| Insecure SSL | 1 |", "| Wildcard events | 1 |", `a=1\|2`, "| 456 | http://example.org/hook | false | * | form | Yes | Missing |"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, report)
+		}
+	}
+}
+
+func TestMarkdownWriterNoWebhooks(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (markdownWriter{}).Write(&buf, "test-org", []data.Webhook{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "No webhooks found.") {
+		t.Errorf("Expected empty report message, got:\n%s", buf.String())
+	}
+}
+
+func TestHTMLWriter(t *testing.T) {
+	hooks := testWebhooks()
+	hooks[0].Config.Url = "https://example.com/<script>"
+
+	var buf bytes.Buffer
+	if err := (htmlWriter{}).Write(&buf, "test-org", hooks); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	report := buf.String()
+	for _, want := range []string{"<h2>test-org</h2>", "<tr><td>Missing secret</td><td>1</td></tr>", "push, pull_request", "&lt;script&gt;"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, report)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"gopkg.in/yaml.v3"
+)
+
+type jsonWriter struct{}
+
+func (jsonWriter) Write(w io.Writer, owner string, hooks []data.Webhook) error {
+	if hooks == nil {
+		hooks = []data.Webhook{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(hooks)
+}
+
+type ndjsonWriter struct{}
+
+func (ndjsonWriter) Write(w io.Writer, owner string, hooks []data.Webhook) error {
+	encoder := json.NewEncoder(w)
+	for _, hook := range hooks {
+		if err := encoder.Encode(hook); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type yamlWriter struct{}
+
+func (yamlWriter) Write(w io.Writer, owner string, hooks []data.Webhook) error {
+	if hooks == nil {
+		hooks = []data.Webhook{}
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(hooks); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
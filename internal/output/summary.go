@@ -0,0 +1,46 @@
+package output
+
+import (
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+)
+
+// Summary counts webhooks by the properties reviewed during access reviews.
+type Summary struct {
+	Total          int `json:"total"`
+	Active         int `json:"active"`
+	Inactive       int `json:"inactive"`
+	InsecureSSL    int `json:"insecure_ssl"`
+	MissingSecret  int `json:"missing_secret"`
+	WildcardEvents int `json:"wildcard_events"`
+}
+
+// Summarize counts the webhooks in each summary category.
+func Summarize(hooks []data.Webhook) Summary {
+	summary := Summary{Total: len(hooks)}
+	for _, hook := range hooks {
+		if hook.Active {
+			summary.Active++
+		} else {
+			summary.Inactive++
+		}
+		if hook.Config.InsecureSSL == "1" {
+			summary.InsecureSSL++
+		}
+		if hook.Config.Secret == "" {
+			summary.MissingSecret++
+		}
+		if hasWildcard(hook.Events) {
+			summary.WildcardEvents++
+		}
+	}
+	return summary
+}
+
+func hasWildcard(events []string) bool {
+	for _, event := range events {
+		if event == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,610 @@
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expressions are evaluated against the fields of a webhook. The language
+// supports string, number, boolean and list literals, the operators
+// `==`, `!=`, `<`, `<=`, `>`, `>=`, `in`, `contains`, `matches`,
+// `startsWith`, `endsWith`, `&&`/`and`, `||`/`or`, `!`/`not`, parentheses
+// and the functions in exprFuncs.
+
+// Expr is a compiled expression.
+type Expr struct {
+	source string
+	root   node
+}
+
+// Env holds the values of the identifiers an expression can reference.
+type Env map[string]interface{}
+
+// Compile parses an expression.
+func Compile(source string) (*Expr, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.peek().text, p.peek().pos)
+	}
+	return &Expr{source: source, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression, which must produce a boolean.
+func (e *Expr) Eval(env Env) (bool, error) {
+	v, err := e.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q evaluated to %v, not a boolean", e.source, v)
+	}
+	return b, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var symbolOps = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ","}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			start := i
+			var b strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				b.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: b.String(), pos: start})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+		default:
+			matched := false
+			for _, op := range symbolOps {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token when it is one of the operators or keywords.
+func (p *parser) accept(values ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOp && t.kind != tokenIdent {
+		return "", false
+	}
+	for _, v := range values {
+		if t.text == v {
+			p.next()
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(value string) error {
+	if _, ok := p.accept(value); !ok {
+		t := p.peek()
+		if t.kind == tokenEOF {
+			return fmt.Errorf("expected %q at end of expression", value)
+		}
+		return fmt.Errorf("expected %q at position %d, found %q", value, t.pos, t.text)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||", "or"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{op: "||", left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&", "and"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{op: "&&", left: left, right: right}
+	}
+}
+
+func (p *parser) parseNot() (node, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+var comparisonOps = []string{"==", "!=", "<=", ">=", "<", ">", "in", "contains", "matches", "startsWith", "endsWith"}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept(comparisonOps...)
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if op == "matches" {
+		lit, isLit := right.(literalNode)
+		if pattern, isString := lit.value.(string); isLit && isString {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+			}
+			return matchNode{left: left, re: re}, nil
+		}
+		return nil, fmt.Errorf("matches requires a string literal pattern")
+	}
+	return comparisonNode{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return literalNode{value: t.text}, nil
+	case tokenNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", t.text, t.pos)
+		}
+		return literalNode{value: n}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(t)
+		}
+		return identNode{name: t.text}, nil
+	case tokenOp:
+		switch t.text {
+		case "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return inner, p.expect(")")
+		case "[":
+			var items []node
+			if _, ok := p.accept("]"); ok {
+				return listNode{items: items}, nil
+			}
+			for {
+				item, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+				if _, ok := p.accept(","); ok {
+					continue
+				}
+				return listNode{items: items}, p.expect("]")
+			}
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := exprFuncs[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos)
+	}
+	call := callNode{name: name.text, fn: fn}
+	if _, ok := p.accept(")"); ok {
+		return call, nil
+	}
+	for {
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		if _, ok := p.accept(","); ok {
+			continue
+		}
+		return call, p.expect(")")
+	}
+}
+
+type node interface {
+	eval(env Env) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n literalNode) eval(env Env) (interface{}, error) {
+	return n.value, nil
+}
+
+type identNode struct {
+	name string
+}
+
+func (n identNode) eval(env Env) (interface{}, error) {
+	v, ok := env[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", n.name)
+	}
+	return v, nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n listNode) eval(env Env) (interface{}, error) {
+	values := make([]interface{}, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) eval(env Env) (interface{}, error) {
+	v, err := evalBool(n.operand, env)
+	if err != nil {
+		return nil, err
+	}
+	return !v, nil
+}
+
+type logicalNode struct {
+	op          string
+	left, right node
+}
+
+func (n logicalNode) eval(env Env) (interface{}, error) {
+	left, err := evalBool(n.left, env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" && !left {
+		return false, nil
+	}
+	if n.op == "||" && left {
+		return true, nil
+	}
+	return evalBool(n.right, env)
+}
+
+type matchNode struct {
+	left node
+	re   *regexp.Regexp
+}
+
+func (n matchNode) eval(env Env) (interface{}, error) {
+	v, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("matches requires a string, got %v", v)
+	}
+	return n.re.MatchString(s), nil
+}
+
+type comparisonNode struct {
+	op          string
+	left, right node
+}
+
+func (n comparisonNode) eval(env Env) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		return contains(right, left)
+	case "contains":
+		return contains(left, right)
+	case "startsWith", "endsWith":
+		ls, lok := left.(string)
+		rs, rok := right.(string)
+		if !lok || !rok {
+			return nil, fmt.Errorf("%s requires strings, got %v and %v", n.op, left, right)
+		}
+		if n.op == "startsWith" {
+			return strings.HasPrefix(ls, rs), nil
+		}
+		return strings.HasSuffix(ls, rs), nil
+	default:
+		return compare(n.op, left, right)
+	}
+}
+
+type callNode struct {
+	name string
+	fn   func(args []interface{}) (interface{}, error)
+	args []node
+}
+
+func (n callNode) eval(env Env) (interface{}, error) {
+	args := make([]interface{}, 0, len(n.args))
+	for _, arg := range n.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	v, err := n.fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+var exprFuncs = map[string]func(args []interface{}) (interface{}, error){
+	// len returns the length of a string or list
+	"len": func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+		switch v := args[0].(type) {
+		case string:
+			return float64(len(v)), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		}
+		return nil, fmt.Errorf("expected a string or list, got %v", args[0])
+	},
+	// intersects reports whether two lists share any element
+	"intersects": func(args []interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expected 2 arguments, got %d", len(args))
+		}
+		left, lok := args[0].([]interface{})
+		right, rok := args[1].([]interface{})
+		if !lok || !rok {
+			return nil, fmt.Errorf("expected two lists")
+		}
+		for _, l := range left {
+			for _, r := range right {
+				if equal(l, r) {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	},
+	// domainIn reports whether a host matches any domain, where `*.example.com`
+	// matches subdomains of example.com
+	"domainIn": func(args []interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expected 2 arguments, got %d", len(args))
+		}
+		host, hok := args[0].(string)
+		domains, dok := args[1].([]interface{})
+		if !hok || !dok {
+			return nil, fmt.Errorf("expected a host and a list of domains")
+		}
+		for _, d := range domains {
+			domain, ok := d.(string)
+			if ok && MatchDomain(host, domain) {
+				return true, nil
+			}
+		}
+		return false, nil
+	},
+}
+
+// MatchDomain reports whether the host is the domain, or a subdomain of it
+// when the domain is written as `*.example.com`.
+func MatchDomain(host, domain string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if suffix, ok := strings.CutPrefix(domain, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == domain
+}
+
+func evalBool(n node, env Env) (bool, error) {
+	v, err := n.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a boolean, got %v", v)
+	}
+	return b, nil
+}
+
+func equal(left, right interface{}) bool {
+	return reflect.DeepEqual(left, right)
+}
+
+func contains(container, item interface{}) (bool, error) {
+	switch c := container.(type) {
+	case []interface{}:
+		for _, v := range c {
+			if equal(v, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case string:
+		s, ok := item.(string)
+		if !ok {
+			return false, fmt.Errorf("cannot search for %v in a string", item)
+		}
+		return strings.Contains(c, s), nil
+	}
+	return false, fmt.Errorf("expected a list or string, got %v", container)
+}
+
+func compare(op string, left, right interface{}) (bool, error) {
+	var cmp int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false, fmt.Errorf("cannot compare %v with %v", left, right)
+		}
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false, fmt.Errorf("cannot compare %v with %v", left, right)
+		}
+		cmp = strings.Compare(l, r)
+	default:
+		return false, fmt.Errorf("cannot compare %v with %v", left, right)
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	}
+	return false, fmt.Errorf("unknown operator %q", op)
+}
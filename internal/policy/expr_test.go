@@ -0,0 +1,119 @@
+package policy
+
+import (
+	"testing"
+)
+
+func testEnv() Env {
+	return Env{
+		"id":           float64(7),
+		"active":       true,
+		"events":       []interface{}{"push", "pull_request"},
+		"content_type": "json",
+		"host":         "hooks.example.com",
+		"url":          "https://hooks.example.com/github",
+	}
+}
+
+func TestExprEval(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`active`, true},
+		{`!active`, false},
+		{`not active or id == 7`, true},
+		{`content_type == "json" && active`, true},
+		{`content_type != 'json'`, false},
+		{`id > 5 and id <= 7`, true},
+		{`id < 7`, false},
+		{`"push" in events`, true},
+		{`"issues" in events`, false},
+		{`events contains "pull_request"`, true},
+		{`host in ["a.example.com", "hooks.example.com"]`, true},
+		{`url startsWith "https://"`, true},
+		{`host endsWith ".example.org"`, false},
+		{`url matches "^https://[a-z]+\\.example\\.com/"`, true},
+		{`"example" in host`, true},
+		{`len(events) == 2`, true},
+		{`intersects(events, ["*", "member"])`, false},
+		{`domainIn(host, ["*.example.com"])`, true},
+		{`domainIn(host, ["example.com"])`, false},
+		{`(active || false) && !(id == 8)`, true},
+		{`[] == []`, true},
+	}
+
+	env := testEnv()
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			got, err := expr.Eval(env)
+			if err != nil {
+				t.Fatalf("Eval() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Eval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExprCompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`active &&`,
+		`(active`,
+		`"unterminated`,
+		`host matches "("`,
+		`host matches host`,
+		`unknown(host)`,
+		`active active`,
+		`id # 3`,
+	} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("Expected compile error for %q, got nil", expr)
+		}
+	}
+}
+
+func TestExprEvalErrors(t *testing.T) {
+	env := testEnv()
+	for _, source := range []string{
+		`missing == 1`,
+		`host`,
+		`id > "a"`,
+		`!host`,
+		`len(active)`,
+		`1 in active`,
+	} {
+		expr, err := Compile(source)
+		if err != nil {
+			t.Fatalf("Compile(%q) error = %v", source, err)
+		}
+		if _, err := expr.Eval(env); err == nil {
+			t.Errorf("Expected evaluation error for %q, got nil", source)
+		}
+	}
+}
+
+func TestMatchDomain(t *testing.T) {
+	tests := []struct {
+		host, domain string
+		want         bool
+	}{
+		{"hooks.example.com", "hooks.example.com", true},
+		{"HOOKS.example.com", "hooks.example.com", true},
+		{"a.b.example.com", "*.example.com", true},
+		{"example.com", "*.example.com", false},
+		{"evilexample.com", "*.example.com", false},
+		{"hooks.example.com.", "hooks.example.com", true},
+	}
+	for _, tt := range tests {
+		if got := MatchDomain(tt.host, tt.domain); got != tt.want {
+			t.Errorf("MatchDomain(%s, %s) = %v, want %v", tt.host, tt.domain, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/lint"
+)
+
+// ErrNoAllowedDomains is returned when webhooks are checked against an empty
+// allowlist, which allows no hosts.
+var ErrNoAllowedDomains = errors.New("no allowed hosts are configured")
+
+// Guard rejects webhooks that should not be created: those delivering over
+// plain HTTP, without SSL verification, or to hosts outside the allowlist.
+// An empty allowlist allows no hosts.
+type Guard struct {
+	AllowedDomains []string
+}
+
+// Violations returns the reasons the webhook must not be created.
+func (g Guard) Violations(hook data.CreatedWebhook) []string {
+	var violations []string
+
+	u, err := url.Parse(hook.Config.Url)
+	if err != nil || u.Hostname() == "" {
+		return []string{fmt.Sprintf("URL %q is not valid", hook.Config.Url)}
+	}
+	if !strings.EqualFold(u.Scheme, "https") {
+		violations = append(violations, fmt.Sprintf("URL uses %s instead of https", u.Scheme))
+	}
+	if hook.Config.InsecureSSL == "1" {
+		violations = append(violations, "SSL certificate verification is disabled (insecure_ssl=1)")
+	}
+	if !g.allowed(u.Hostname()) {
+		violations = append(violations, fmt.Sprintf("host %s is not in the allowlist", u.Hostname()))
+	}
+	return violations
+}
+
+// Check returns an error describing every webhook with violations.
+func (g Guard) Check(hooks []data.CreatedWebhook) error {
+	if len(hooks) > 0 && len(g.AllowedDomains) == 0 {
+		return ErrNoAllowedDomains
+	}
+	var rejected []string
+	for _, hook := range hooks {
+		if violations := g.Violations(hook); len(violations) > 0 {
+			rejected = append(rejected, fmt.Sprintf("%s: %s", lint.RedactURL(hook.Config.Url), strings.Join(violations, "; ")))
+		}
+	}
+	if len(rejected) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d webhook(s) were rejected:\n  %s", len(rejected), strings.Join(rejected, "\n  "))
+}
+
+func (g Guard) allowed(host string) bool {
+	for _, domain := range g.AllowedDomains {
+		if MatchDomain(host, domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package policy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+)
+
+func guardWebhook(url, insecureSSL string) data.CreatedWebhook {
+	return data.CreatedWebhook{Name: "web", Config: data.Config{Url: url, InsecureSSL: insecureSSL}}
+}
+
+func TestGuardViolations(t *testing.T) {
+	guard := Guard{AllowedDomains: []string{"hooks.example.com", "*.corp.example.com"}}
+
+	tests := []struct {
+		name string
+		hook data.CreatedWebhook
+		want int
+	}{
+		{name: "allowed", hook: guardWebhook("https://hooks.example.com/github", "0"), want: 0},
+		{name: "allowed subdomain", hook: guardWebhook("https://ci.corp.example.com/github", "0"), want: 0},
+		{name: "not allowed", hook: guardWebhook("https://exfil.example.net/collect", "0"), want: 1},
+		{name: "http", hook: guardWebhook("http://hooks.example.com/github", "0"), want: 1},
+		{name: "insecure ssl", hook: guardWebhook("https://hooks.example.com/github", "1"), want: 1},
+		{name: "all", hook: guardWebhook("http://exfil.example.net/collect", "1"), want: 3},
+		{name: "invalid", hook: guardWebhook("not a url", "0"), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := guard.Violations(tt.hook); len(got) != tt.want {
+				t.Errorf("Expected %d violations, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGuardWithoutAllowlist(t *testing.T) {
+	guard := Guard{}
+	if got := guard.Violations(guardWebhook("https://anywhere.example.org/hook", "0")); len(got) != 1 {
+		t.Errorf("Expected every host to be rejected without an allowlist, got %v", got)
+	}
+	if err := guard.Check([]data.CreatedWebhook{guardWebhook("https://anywhere.example.org/hook", "0")}); !errors.Is(err, ErrNoAllowedDomains) {
+		t.Errorf("Expected ErrNoAllowedDomains, got %v", err)
+	}
+	if err := guard.Check(nil); err != nil {
+		t.Errorf("Expected no error without webhooks, got %v", err)
+	}
+}
+
+func TestGuardCheck(t *testing.T) {
+	guard := Guard{AllowedDomains: []string{"hooks.example.com"}}
+
+	if err := guard.Check([]data.CreatedWebhook{guardWebhook("https://hooks.example.com/a", "0")}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	err := guard.Check([]data.CreatedWebhook{
+		guardWebhook("https://hooks.example.com/a", "0"),
+		guardWebhook("https://exfil.example.net/collect?token=abc", "0"),
+	})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "1 webhook(s)") || strings.Contains(err.Error(), "abc") {
+		t.Errorf("Unexpected error message %v", err)
+	}
+}
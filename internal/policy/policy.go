@@ -0,0 +1,200 @@
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/lint"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy is an organization's webhook compliance policy. The built-in settings
+// are translated to rules written in the expression language, and custom
+// rules can be added alongside them.
+type Policy struct {
+	AllowedDomains      []string `yaml:"allowed_domains"`
+	RequiredContentType string   `yaml:"required_content_type"`
+	ForbiddenEvents     []string `yaml:"forbidden_events"`
+	MaxHooksPerOrg      int      `yaml:"max_hooks_per_org"`
+	Rules               []Rule   `yaml:"rules"`
+
+	compiled []compiledRule
+}
+
+// Rule is a custom policy rule. A webhook complies when Expr evaluates to true.
+type Rule struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Level       string `yaml:"level"`
+	Expr        string `yaml:"expr"`
+}
+
+type compiledRule struct {
+	Rule
+	level lint.Level
+	expr  *Expr
+}
+
+// Load reads and compiles a policy file.
+func Load(path string) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read policy file: %w", err)
+	}
+	return Parse(b)
+}
+
+// Parse parses and compiles a YAML policy.
+func Parse(b []byte) (*Policy, error) {
+	var p Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid policy file: %w", err)
+	}
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (p *Policy) compile() error {
+	var rules []Rule
+	if len(p.AllowedDomains) > 0 {
+		rules = append(rules, Rule{
+			Name:        "allowed-domains",
+			Description: fmt.Sprintf("URL host must be one of: %s", strings.Join(p.AllowedDomains, ", ")),
+			Expr:        fmt.Sprintf("domainIn(host, %s)", listLiteral(p.AllowedDomains)),
+		})
+	}
+	if p.RequiredContentType != "" {
+		rules = append(rules, Rule{
+			Name:        "required-content-type",
+			Description: fmt.Sprintf("content type must be %s", p.RequiredContentType),
+			Expr:        fmt.Sprintf("content_type == %s", strconv.Quote(p.RequiredContentType)),
+		})
+	}
+	if len(p.ForbiddenEvents) > 0 {
+		rules = append(rules, Rule{
+			Name:        "forbidden-events",
+			Description: fmt.Sprintf("must not subscribe to: %s", strings.Join(p.ForbiddenEvents, ", ")),
+			Expr:        fmt.Sprintf("!intersects(events, %s)", listLiteral(p.ForbiddenEvents)),
+		})
+	}
+	rules = append(rules, p.Rules...)
+
+	p.compiled = nil
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("policy rule %d has no name", i+1)
+		}
+		if rule.Level == "" {
+			rule.Level = string(lint.LevelError)
+		}
+		level, err := lint.ParseLevel(rule.Level)
+		if err != nil || level == "none" {
+			return fmt.Errorf("policy rule %s has an invalid level %q", rule.Name, rule.Level)
+		}
+		expr, err := Compile(rule.Expr)
+		if err != nil {
+			return fmt.Errorf("policy rule %s has an invalid expression: %w", rule.Name, err)
+		}
+		p.compiled = append(p.compiled, compiledRule{Rule: rule, level: level, expr: expr})
+	}
+	return nil
+}
+
+// Check evaluates the policy against an organization's webhooks and returns
+// the violations as findings.
+func (p *Policy) Check(owner string, hooks []data.Webhook) ([]lint.Finding, error) {
+	findings := []lint.Finding{}
+	if p.MaxHooksPerOrg > 0 && len(hooks) > p.MaxHooksPerOrg {
+		findings = append(findings, lint.Finding{
+			RuleID:       "max-hooks-per-org",
+			RuleName:     "max-hooks-per-org",
+			Level:        lint.LevelError,
+			Message:      fmt.Sprintf("organization has %d webhooks, more than the maximum of %d", len(hooks), p.MaxHooksPerOrg),
+			Organization: owner,
+		})
+	}
+
+	for _, hook := range hooks {
+		env := WebhookEnv(hook)
+		for _, rule := range p.compiled {
+			ok, err := rule.expr.Eval(env)
+			if err != nil {
+				return nil, fmt.Errorf("evaluating policy rule %s for webhook %d: %w", rule.Name, hook.ID, err)
+			}
+			if ok {
+				continue
+			}
+			message := rule.Description
+			if message == "" {
+				message = fmt.Sprintf("expression %s is false", rule.expr)
+			}
+			findings = append(findings, lint.Finding{
+				RuleID:       rule.Name,
+				RuleName:     rule.Name,
+				Level:        rule.level,
+				Message:      message,
+				Organization: owner,
+				HookID:       hook.ID,
+				URL:          lint.RedactURL(hook.Config.Url),
+			})
+		}
+	}
+	return findings, nil
+}
+
+// WebhookEnv exposes a webhook's fields to policy expressions.
+func WebhookEnv(hook data.Webhook) Env {
+	events := make([]interface{}, 0, len(hook.Events))
+	for _, event := range hook.Events {
+		events = append(events, event)
+	}
+
+	var scheme, host, path string
+	if u, err := url.Parse(hook.Config.Url); err == nil {
+		scheme = strings.ToLower(u.Scheme)
+		host = strings.ToLower(u.Hostname())
+		path = u.Path
+	}
+
+	var ageDays float64
+	if !hook.CreatedAt.IsZero() {
+		ageDays = float64(int(time.Since(hook.CreatedAt).Hours() / 24))
+	}
+
+	return Env{
+		"id":           float64(hook.ID),
+		"name":         hook.Name,
+		"active":       hook.Active,
+		"events":       events,
+		"content_type": hook.Config.ContentType,
+		"insecure_ssl": hook.Config.InsecureSSL == "1",
+		"has_secret":   hook.Config.Secret != "",
+		"url":          hook.Config.Url,
+		"scheme":       scheme,
+		"host":         host,
+		"path":         path,
+		"created_at":   hook.CreatedAt.UTC().Format(time.RFC3339),
+		"updated_at":   hook.UpdatedAt.UTC().Format(time.RFC3339),
+		"age_days":     ageDays,
+	}
+}
+
+func listLiteral(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, strconv.Quote(v))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
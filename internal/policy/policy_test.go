@@ -0,0 +1,133 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/internal/lint"
+)
+
+const testPolicy = `
+allowed_domains:
+  - hooks.example.com
+  - "*.corp.example.com"
+required_content_type: json
+forbidden_events: ["*", "member"]
+max_hooks_per_org: 2
+rules:
+  - name: require-secret
+    description: webhooks must have a secret
+    expr: has_secret
+  - name: recent
+    level: warning
+    expr: age_days < 365
+`
+
+func policyWebhooks() []data.Webhook {
+	return []data.Webhook{
+		{
+			ID:        1,
+			Active:    true,
+			Events:    []string{"push"},
+			Config:    data.Config{ContentType: "json", Secret: "********", Url: "https://hooks.example.com/github"},
+			CreatedAt: time.Now(),
+		},
+		{
+			ID:        2,
+			Active:    true,
+			Events:    []string{"*"},
+			Config:    data.Config{ContentType: "form", Url: "https://ci.corp.example.com/hook?token=abc"},
+			CreatedAt: time.Now().AddDate(-2, 0, 0),
+		},
+		{
+			ID:        3,
+			Active:    true,
+			Events:    []string{"push"},
+			Config:    data.Config{ContentType: "json", Secret: "********", Url: "https://attacker.example.net/collect"},
+			CreatedAt: time.Now(),
+		},
+	}
+}
+
+func TestParseAndCheck(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	findings, err := p.Check("test-org", policyWebhooks())
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	got := map[string][]int{}
+	for _, f := range findings {
+		got[f.RuleID] = append(got[f.RuleID], f.HookID)
+	}
+
+	want := map[string][]int{
+		"max-hooks-per-org":     {0},
+		"allowed-domains":       {3},
+		"required-content-type": {2},
+		"forbidden-events":      {2},
+		"require-secret":        {2},
+		"recent":                {2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected findings for %v, got %v", want, got)
+	}
+	for rule, hooks := range want {
+		if len(got[rule]) != len(hooks) || got[rule][0] != hooks[0] {
+			t.Errorf("Expected %s findings for hooks %v, got %v", rule, hooks, got[rule])
+		}
+	}
+
+	for _, f := range findings {
+		if f.RuleID == "recent" && f.Level != lint.LevelWarning {
+			t.Errorf("Expected recent rule to be a warning, got %s", f.Level)
+		}
+		if f.HookID == 2 && f.URL != "https://ci.corp.example.com/hook?token=redacted" {
+			t.Errorf("Expected URL to be redacted, got %s", f.URL)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, source := range []string{
+		"unknown_setting: true",
+		"rules:\n  - expr: active",
+		"rules:\n  - name: bad\n    expr: 'active &&'",
+		"rules:\n  - name: bad\n    level: critical\n    expr: active",
+	} {
+		if _, err := Parse([]byte(source)); err == nil {
+			t.Errorf("Expected error for policy %q, got nil", source)
+		}
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	p, err := Parse([]byte(""))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	findings, err := p.Check("test-org", policyWebhooks())
+	if err != nil || len(findings) != 0 {
+		t.Errorf("Expected no findings, got %v (%v)", findings, err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yml")
+	if err := os.WriteFile(path, []byte(testPolicy), 0644); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+	if _, err := Load(path); err != nil {
+		t.Errorf("Load() error = %v", err)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yml")); err == nil {
+		t.Error("Expected error for missing file, got nil")
+	}
+}
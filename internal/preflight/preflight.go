@@ -0,0 +1,263 @@
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+)
+
+// MaxWebhooksPerEvent is GitHub's limit on webhooks subscribed to the same
+// event for a single organization.
+const MaxWebhooksPerEvent = 20
+
+// RequiredScope is the classic token scope needed to read and write organization webhooks.
+const RequiredScope = "admin:org_hook"
+
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+type Check struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+}
+
+type Report struct {
+	Organization string  `json:"organization"`
+	Checks       []Check `json:"checks"`
+}
+
+// Getter is the subset of the API used by the preflight checks.
+type Getter interface {
+	GetTokenScopes(ctx context.Context) ([]string, bool, error)
+	GetOrganization(ctx context.Context, owner string) ([]byte, error)
+	GetOrganizationMembership(ctx context.Context, owner string) ([]byte, error)
+	GetOrganizationWebhooks(ctx context.Context, owner string) ([]byte, error)
+}
+
+// Run verifies that the token can manage webhooks for the organization and that
+// creating the planned webhooks stays within GitHub's per-organization limits.
+// usesApp is set when authenticating as a GitHub App, whose tokens have no
+// organization membership.
+func Run(ctx context.Context, g Getter, owner string, planned []data.CreatedWebhook, usesApp bool) Report {
+	report := Report{Organization: owner}
+	report.add(checkScopes(ctx, g))
+
+	orgCheck := checkOrganization(ctx, g, owner)
+	report.add(orgCheck)
+	if orgCheck.Status == StatusFail {
+		return report
+	}
+
+	report.add(checkMembership(ctx, g, owner, usesApp))
+	report.add(checkWebhookLimit(ctx, g, owner, planned))
+	return report
+}
+
+// Failed reports whether any check failed.
+func (r Report) Failed() bool {
+	for _, c := range r.Checks {
+		if c.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns an error summarizing the failed checks, or nil when none failed.
+func (r Report) Err() error {
+	var failed []string
+	for _, c := range r.Checks {
+		if c.Status == StatusFail {
+			failed = append(failed, fmt.Sprintf("%s: %s", c.Name, c.Message))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("preflight checks failed for %s:\n  %s", r.Organization, strings.Join(failed, "\n  "))
+}
+
+// Write prints the report as an aligned table.
+func (r Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Preflight checks for %s\n", r.Organization)
+	for _, c := range r.Checks {
+		fmt.Fprintf(tw, "  [%s]\t%s\t%s\n", strings.ToUpper(string(c.Status)), c.Name, c.Message)
+	}
+	return tw.Flush()
+}
+
+func (r *Report) add(c Check) {
+	r.Checks = append(r.Checks, c)
+}
+
+func checkScopes(ctx context.Context, g Getter) Check {
+	check := Check{Name: "Token scopes"}
+	scopes, known, err := g.GetTokenScopes(ctx)
+	switch {
+	case err != nil:
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("unable to authenticate: %v", err)
+	case !known:
+		check.Status = StatusSkip
+		check.Message = "scopes are not reported for fine-grained or GitHub App tokens"
+	case hasScope(scopes, RequiredScope):
+		check.Status = StatusPass
+		check.Message = fmt.Sprintf("token has the %s scope", RequiredScope)
+	default:
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("token is missing the %s scope (has: %s)", RequiredScope, strings.Join(scopes, ", "))
+	}
+	return check
+}
+
+func checkOrganization(ctx context.Context, g Getter, owner string) Check {
+	check := Check{Name: "Organization"}
+	body, err := g.GetOrganization(ctx, owner)
+	if err != nil {
+		check.Status = StatusFail
+		if statusCode(err) == http.StatusNotFound {
+			check.Message = fmt.Sprintf("organization %s does not exist or is not visible to the token", owner)
+		} else {
+			check.Message = fmt.Sprintf("unable to retrieve organization %s: %v", owner, err)
+		}
+		return check
+	}
+	var org data.Organization
+	if err := json.Unmarshal(body, &org); err != nil {
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("unable to read organization %s: %v", owner, err)
+		return check
+	}
+	check.Status = StatusPass
+	check.Message = fmt.Sprintf("organization %s exists (id %d)", org.Login, org.ID)
+	return check
+}
+
+func checkMembership(ctx context.Context, g Getter, owner string, usesApp bool) Check {
+	check := Check{Name: "Membership role"}
+	body, err := g.GetOrganizationMembership(ctx, owner)
+	if err != nil {
+		code := statusCode(err)
+		switch {
+		case usesApp && (code == http.StatusForbidden || code == http.StatusNotFound):
+			check.Status = StatusWarn
+			check.Message = "membership could not be determined (GitHub App tokens have no membership)"
+		case code == http.StatusNotFound:
+			check.Status = StatusFail
+			check.Message = fmt.Sprintf("user is not a member of %s", owner)
+		case code == http.StatusForbidden:
+			check.Status = StatusWarn
+			check.Message = "membership could not be determined (the token can't read organization memberships)"
+		default:
+			check.Status = StatusFail
+			check.Message = fmt.Sprintf("unable to retrieve membership: %v", err)
+		}
+		return check
+	}
+	var membership data.Membership
+	if err := json.Unmarshal(body, &membership); err != nil {
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("unable to read membership: %v", err)
+		return check
+	}
+	if membership.State != "active" || membership.Role != "admin" {
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("user must be an active organization owner (role: %s, state: %s)", membership.Role, membership.State)
+		return check
+	}
+	check.Status = StatusPass
+	check.Message = "user is an organization owner"
+	return check
+}
+
+func checkWebhookLimit(ctx context.Context, g Getter, owner string, planned []data.CreatedWebhook) Check {
+	check := Check{Name: "Webhook limit"}
+	body, err := g.GetOrganizationWebhooks(ctx, owner)
+	if err != nil {
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("unable to list webhooks: %v", err)
+		return check
+	}
+	var existing []data.Webhook
+	if err := json.Unmarshal(body, &existing); err != nil {
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("unable to read webhooks: %v", err)
+		return check
+	}
+
+	counts := map[string]int{}
+	for _, hook := range existing {
+		countEvents(counts, hook.Events)
+	}
+	for _, hook := range planned {
+		countEvents(counts, hook.Events)
+	}
+
+	// Webhooks subscribed to all events count against every event, so they
+	// are added to the busiest one even when no other webhook names it
+	wildcard := counts["*"]
+	delete(counts, "*")
+	if wildcard > 0 && len(counts) == 0 {
+		counts["*"] = 0
+	}
+	var exceeded []string
+	for event, count := range counts {
+		if count+wildcard > MaxWebhooksPerEvent {
+			exceeded = append(exceeded, fmt.Sprintf("%s (%d)", event, count+wildcard))
+		}
+	}
+	sort.Strings(exceeded)
+
+	if len(exceeded) > 0 {
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("more than %d webhooks would subscribe to: %s", MaxWebhooksPerEvent, strings.Join(exceeded, ", "))
+		return check
+	}
+	check.Status = StatusPass
+	check.Message = fmt.Sprintf("%d existing and %d planned webhook(s) are within the limit of %d per event", len(existing), len(planned), MaxWebhooksPerEvent)
+	return check
+}
+
+func countEvents(counts map[string]int, events []string) {
+	if len(events) == 0 {
+		// GitHub subscribes webhooks created without events to push
+		events = []string{"push"}
+	}
+	for _, event := range events {
+		counts[event]++
+	}
+}
+
+func hasScope(scopes []string, want string) bool {
+	for _, scope := range scopes {
+		if scope == want {
+			return true
+		}
+	}
+	return false
+}
+
+func statusCode(err error) int {
+	var httpErr *api.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode
+	}
+	return 0
+}
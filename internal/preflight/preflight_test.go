@@ -0,0 +1,189 @@
+package preflight
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+)
+
+func healthyGetter() *data.MockAPIGetter {
+	mockGetter := data.NewMockAPIGetter()
+	mockGetter.TokenScopes = []string{"admin:org_hook"}
+	mockGetter.TokenScopesKnown = true
+	mockGetter.OrganizationData = []byte(`{"login":"test-org","id":1}`)
+	mockGetter.MembershipData = []byte(`{"state":"active","role":"admin"}`)
+	mockGetter.OrganizationWebhooksData = []byte(`[]`)
+	return mockGetter
+}
+
+func checkStatus(t *testing.T, report Report, name string, want Status) {
+	t.Helper()
+	for _, c := range report.Checks {
+		if c.Name == name {
+			if c.Status != want {
+				t.Errorf("Expected %s to be %s, got %s (%s)", name, want, c.Status, c.Message)
+			}
+			return
+		}
+	}
+	t.Errorf("Check %s not found in report", name)
+}
+
+func TestRunPasses(t *testing.T) {
+	report := Run(context.Background(), healthyGetter(), "test-org", nil, false)
+
+	if report.Failed() {
+		t.Errorf("Expected report to pass, got %v", report.Err())
+	}
+	if len(report.Checks) != 4 {
+		t.Errorf("Expected 4 checks, got %d", len(report.Checks))
+	}
+}
+
+func TestRunMissingScope(t *testing.T) {
+	mockGetter := healthyGetter()
+	mockGetter.TokenScopes = []string{"repo", "read:org"}
+
+	report := Run(context.Background(), mockGetter, "test-org", nil, false)
+	checkStatus(t, report, "Token scopes", StatusFail)
+	if !strings.Contains(report.Err().Error(), RequiredScope) {
+		t.Errorf("Expected error to mention %s, got %v", RequiredScope, report.Err())
+	}
+}
+
+func TestRunUnknownScopes(t *testing.T) {
+	mockGetter := healthyGetter()
+	mockGetter.TokenScopesKnown = false
+	mockGetter.MethodErrors = map[string]error{
+		"GetOrganizationMembership": &api.HTTPError{StatusCode: http.StatusForbidden},
+	}
+
+	report := Run(context.Background(), mockGetter, "test-org", nil, false)
+	checkStatus(t, report, "Token scopes", StatusSkip)
+	checkStatus(t, report, "Membership role", StatusWarn)
+	if report.Failed() {
+		t.Errorf("Expected report to pass, got %v", report.Err())
+	}
+}
+
+func TestRunOrganizationNotFound(t *testing.T) {
+	mockGetter := healthyGetter()
+	mockGetter.MethodErrors = map[string]error{
+		"GetOrganization": &api.HTTPError{StatusCode: http.StatusNotFound},
+	}
+
+	report := Run(context.Background(), mockGetter, "missing-org", nil, false)
+	checkStatus(t, report, "Organization", StatusFail)
+	if len(report.Checks) != 2 {
+		t.Errorf("Expected checks to stop after missing organization, got %d checks", len(report.Checks))
+	}
+}
+
+func TestRunNotMember(t *testing.T) {
+	mockGetter := healthyGetter()
+	mockGetter.MethodErrors = map[string]error{
+		"GetOrganizationMembership": &api.HTTPError{StatusCode: http.StatusNotFound},
+	}
+
+	report := Run(context.Background(), mockGetter, "test-org", nil, false)
+	checkStatus(t, report, "Membership role", StatusFail)
+
+	report = Run(context.Background(), mockGetter, "test-org", nil, true)
+	checkStatus(t, report, "Membership role", StatusWarn)
+}
+
+func TestRunNotOwner(t *testing.T) {
+	mockGetter := healthyGetter()
+	mockGetter.MembershipData = []byte(`{"state":"active","role":"member"}`)
+
+	report := Run(context.Background(), mockGetter, "test-org", nil, false)
+	checkStatus(t, report, "Membership role", StatusFail)
+}
+
+func TestRunWebhookLimit(t *testing.T) {
+	var existing []data.Webhook
+	for i := 0; i < MaxWebhooksPerEvent; i++ {
+		existing = append(existing, data.Webhook{ID: i, Events: []string{"push"}})
+	}
+	body, _ := json.Marshal(existing)
+
+	mockGetter := healthyGetter()
+	mockGetter.OrganizationWebhooksData = body
+
+	report := Run(context.Background(), mockGetter, "test-org", []data.CreatedWebhook{{Events: []string{"issues"}}}, false)
+	checkStatus(t, report, "Webhook limit", StatusPass)
+
+	report = Run(context.Background(), mockGetter, "test-org", []data.CreatedWebhook{{Events: []string{"push"}}}, false)
+	checkStatus(t, report, "Webhook limit", StatusFail)
+
+	// Webhooks without events default to push
+	report = Run(context.Background(), mockGetter, "test-org", []data.CreatedWebhook{{}}, false)
+	checkStatus(t, report, "Webhook limit", StatusFail)
+}
+
+func TestRunWebhookLimitWildcard(t *testing.T) {
+	var existing []data.Webhook
+	for i := 0; i < MaxWebhooksPerEvent-1; i++ {
+		existing = append(existing, data.Webhook{ID: i, Events: []string{"*"}})
+	}
+	existing = append(existing, data.Webhook{ID: MaxWebhooksPerEvent, Events: []string{"issues"}})
+	body, _ := json.Marshal(existing)
+
+	mockGetter := healthyGetter()
+	mockGetter.OrganizationWebhooksData = body
+
+	// Existing wildcard webhooks count against the planned webhook's event
+	report := Run(context.Background(), mockGetter, "test-org", []data.CreatedWebhook{{Events: []string{"push"}}}, false)
+	checkStatus(t, report, "Webhook limit", StatusPass)
+	report = Run(context.Background(), mockGetter, "test-org", []data.CreatedWebhook{{Events: []string{"issues"}}}, false)
+	checkStatus(t, report, "Webhook limit", StatusFail)
+
+	// A planned wildcard webhook counts against the busiest event
+	report = Run(context.Background(), mockGetter, "test-org", []data.CreatedWebhook{{Events: []string{"*"}}}, false)
+	checkStatus(t, report, "Webhook limit", StatusFail)
+	var out bytes.Buffer
+	_ = report.Write(&out)
+	if !strings.Contains(out.String(), "issues (21)") {
+		t.Errorf("Expected the busiest event to be reported, got %q", out.String())
+	}
+
+	// Wildcard webhooks alone are still limited
+	var wildcards []data.Webhook
+	for i := 0; i < MaxWebhooksPerEvent; i++ {
+		wildcards = append(wildcards, data.Webhook{ID: i, Events: []string{"*"}})
+	}
+	mockGetter.OrganizationWebhooksData, _ = json.Marshal(wildcards)
+	report = Run(context.Background(), mockGetter, "test-org", []data.CreatedWebhook{{Events: []string{"*"}}}, false)
+	checkStatus(t, report, "Webhook limit", StatusFail)
+}
+
+func TestRunWebhookListError(t *testing.T) {
+	mockGetter := healthyGetter()
+	mockGetter.MethodErrors = map[string]error{
+		"GetOrganizationWebhooks": fmt.Errorf("boom"),
+	}
+
+	report := Run(context.Background(), mockGetter, "test-org", nil, false)
+	checkStatus(t, report, "Webhook limit", StatusFail)
+}
+
+func TestReportWrite(t *testing.T) {
+	report := Report{
+		Organization: "test-org",
+		Checks:       []Check{{Name: "Token scopes", Status: StatusPass, Message: "ok"}},
+	}
+	var buf bytes.Buffer
+	if err := report.Write(&buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "[PASS]") || !strings.Contains(buf.String(), "test-org") {
+		t.Errorf("Unexpected report output: %s", buf.String())
+	}
+}
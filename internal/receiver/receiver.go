@@ -0,0 +1,101 @@
+package receiver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/jsonpretty"
+	"github.com/katiem0/gh-organization-webhooks/pkg/signature"
+	"go.uber.org/zap"
+)
+
+// MaxPayloadSize is the largest payload GitHub delivers.
+const MaxPayloadSize = 25 << 20
+
+// Handler accepts webhook deliveries, verifies their signature when a
+// secret is configured, and prints each delivery.
+type Handler struct {
+	Secret string
+	// AllowSHA1 accepts the legacy X-Hub-Signature header when a delivery has
+	// no X-Hub-Signature-256 header
+	AllowSHA1 bool
+	Out       io.Writer
+	Colorize  bool
+
+	mu sync.Mutex
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxPayloadSize+1))
+	if err != nil {
+		http.Error(w, "unable to read body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > MaxPayloadSize {
+		http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	event := r.Header.Get("X-GitHub-Event")
+	delivery := r.Header.Get("X-GitHub-Delivery")
+
+	verification := "not verified (no secret configured)"
+	if h.Secret != "" {
+		verify := signature.VerifyHeaders
+		if h.AllowSHA1 {
+			verify = signature.VerifyHeadersAllowSHA1
+		}
+		if err := verify([]byte(h.Secret), body, r.Header); err != nil {
+			zap.S().Warnf("Rejected delivery %s for event %s: %v", delivery, event, err)
+			h.print(r, body, "INVALID")
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		verification = "valid"
+	}
+
+	h.print(r, body, verification)
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = fmt.Fprintln(w, "Accepted")
+}
+
+func (h *Handler) print(r *http.Request, body []byte, verification string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(h.Out, "=== %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(h.Out, "Event:     %s\n", headerOrNone(r, "X-GitHub-Event"))
+	fmt.Fprintf(h.Out, "Delivery:  %s\n", headerOrNone(r, "X-GitHub-Delivery"))
+	fmt.Fprintf(h.Out, "Hook ID:   %s\n", headerOrNone(r, "X-GitHub-Hook-ID"))
+	fmt.Fprintf(h.Out, "Signature: %s\n", verification)
+	fmt.Fprintf(h.Out, "Payload:\n")
+
+	payload := body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if values, err := url.ParseQuery(string(body)); err == nil && values.Has("payload") {
+			payload = []byte(values.Get("payload"))
+		}
+	}
+	if err := jsonpretty.Format(h.Out, bytes.NewReader(payload), "  ", h.Colorize); err != nil {
+		fmt.Fprintf(h.Out, "%s\n", payload)
+	}
+	fmt.Fprintln(h.Out)
+}
+
+func headerOrNone(r *http.Request, name string) string {
+	if v := r.Header.Get(name); v != "" {
+		return v
+	}
+	return "(none)"
+}
@@ -0,0 +1,107 @@
+package receiver
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-organization-webhooks/pkg/signature"
+)
+
+func sign(secret string, body []byte) string {
+	return signature.SHA256([]byte(secret), body)
+}
+
+func deliver(h http.Handler, method string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/", bytes.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerValidSignature(t *testing.T) {
+	var out bytes.Buffer
+	h := &Handler{Secret: "s3cret", Out: &out}
+	body := []byte(`{"zen":"Keep it logically awesome.","hook_id":1}`)
+
+	rec := deliver(h, http.MethodPost, body, map[string]string{
+		"Content-Type":        "application/json",
+		"X-GitHub-Event":      "ping",
+		"X-GitHub-Delivery":   "72d3162e-cc78-11e3-81ab-4c9367dc0958",
+		"X-Hub-Signature-256": sign("s3cret", body),
+	})
+
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", rec.Code)
+	}
+	for _, want := range []string{"Event:     ping", "72d3162e-cc78-11e3-81ab-4c9367dc0958", "Signature: valid", `"zen": "Keep it logically awesome."`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestHandlerInvalidSignature(t *testing.T) {
+	var out bytes.Buffer
+	h := &Handler{Secret: "s3cret", Out: &out}
+	body := []byte(`{}`)
+
+	for _, sig := range []string{"", sign("wrong", body), "sha256=zz", "sha1=abc"} {
+		rec := deliver(h, http.MethodPost, body, map[string]string{"X-Hub-Signature-256": sig})
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for signature %q, got %d", sig, rec.Code)
+		}
+	}
+}
+
+func TestHandlerSHA1Fallback(t *testing.T) {
+	body := []byte(`{}`)
+	headers := map[string]string{"X-Hub-Signature": signature.SHA1([]byte("s3cret"), body)}
+
+	h := &Handler{Secret: "s3cret", Out: &bytes.Buffer{}}
+	if rec := deliver(h, http.MethodPost, body, headers); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for a SHA-1 signature by default, got %d", rec.Code)
+	}
+
+	h.AllowSHA1 = true
+	if rec := deliver(h, http.MethodPost, body, headers); rec.Code != http.StatusAccepted {
+		t.Errorf("Expected status 202 for a SHA-1 signature with AllowSHA1, got %d", rec.Code)
+	}
+}
+
+func TestHandlerWithoutSecret(t *testing.T) {
+	var out bytes.Buffer
+	h := &Handler{Out: &out}
+
+	rec := deliver(h, http.MethodPost, []byte(`{}`), nil)
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", rec.Code)
+	}
+	if !strings.Contains(out.String(), "not verified") {
+		t.Errorf("Expected unverified delivery, got:\n%s", out.String())
+	}
+}
+
+func TestHandlerFormPayload(t *testing.T) {
+	var out bytes.Buffer
+	h := &Handler{Out: &out}
+	body := []byte("payload=" + url.QueryEscape(`{"action":"opened"}`))
+
+	deliver(h, http.MethodPost, body, map[string]string{"Content-Type": "application/x-www-form-urlencoded"})
+	if !strings.Contains(out.String(), `"action": "opened"`) {
+		t.Errorf("Expected form payload to be decoded, got:\n%s", out.String())
+	}
+}
+
+func TestHandlerMethodNotAllowed(t *testing.T) {
+	h := &Handler{Out: &bytes.Buffer{}}
+	if rec := deliver(h, http.MethodGet, nil, nil); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,160 @@
+// Package relay re-sends recorded webhook deliveries to another endpoint,
+// re-signing the payload with a local secret.
+package relay
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/pkg/signature"
+)
+
+// skippedHeaders are recorded request headers that are not replayed, either
+// because net/http manages them or because they are recomputed.
+var skippedHeaders = map[string]bool{
+	"Host":                 true,
+	"Content-Length":       true,
+	"Connection":           true,
+	"Accept-Encoding":      true,
+	"Transfer-Encoding":    true,
+	signature.HeaderSHA1:   true,
+	signature.HeaderSHA256: true,
+}
+
+// Result is the outcome of sending a delivery to the target URL.
+type Result struct {
+	StatusCode int
+	Duration   time.Duration
+}
+
+// Payload returns the request body GitHub sent for the delivery. Deliveries
+// made with the form content type are encoded as payload=<json>.
+func Payload(d data.HookDelivery) []byte {
+	payload := bytes.TrimSpace(d.Request.Payload)
+	if len(payload) == 0 || bytes.Equal(payload, []byte("null")) {
+		return nil
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, payload); err == nil {
+		payload = compact.Bytes()
+	}
+	if strings.HasPrefix(header(d, "Content-Type"), "application/x-www-form-urlencoded") {
+		return []byte("payload=" + url.QueryEscape(string(payload)))
+	}
+	return payload
+}
+
+// NewRequest builds a POST of the delivery to target with the recorded
+// headers. The payload is signed with secret, and the recorded signature
+// headers are dropped when no secret is given.
+func NewRequest(ctx context.Context, target string, d data.HookDelivery, secret []byte) (*http.Request, error) {
+	body := Payload(d)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range d.Request.Headers {
+		if skippedHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if req.Header.Get("X-GitHub-Event") == "" && d.Event != "" {
+		req.Header.Set("X-GitHub-Event", d.Event)
+	}
+	if req.Header.Get("X-GitHub-Delivery") == "" && d.GUID != "" {
+		req.Header.Set("X-GitHub-Delivery", d.GUID)
+	}
+	if len(secret) > 0 {
+		signature.SetHeaders(req.Header, secret, body)
+	}
+	return req, nil
+}
+
+// Send posts the delivery to target and returns the response status and
+// round trip duration.
+func Send(ctx context.Context, client *http.Client, target string, d data.HookDelivery, secret []byte) (Result, error) {
+	req, err := NewRequest(ctx, target, d, secret)
+	if err != nil {
+		return Result{}, err
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return Result{Duration: time.Since(start)}, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Error closing response body: %v", err)
+		}
+	}()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return Result{StatusCode: resp.StatusCode, Duration: time.Since(start)}, nil
+}
+
+// ReadDelivery decodes a delivery saved as JSON, transparently decompressing
+// gzip input.
+func ReadDelivery(r io.Reader) (data.HookDelivery, error) {
+	var d data.HookDelivery
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return d, err
+		}
+		defer func() {
+			if err := gz.Close(); err != nil {
+				log.Printf("Error closing gzip reader: %v", err)
+			}
+		}()
+		r = gz
+	} else {
+		r = br
+	}
+	if err := json.NewDecoder(r).Decode(&d); err != nil {
+		return d, fmt.Errorf("unable to decode delivery: %w", err)
+	}
+	return d, nil
+}
+
+// LoadDelivery reads a delivery from a JSON or gzipped JSON file.
+func LoadDelivery(path string) (data.HookDelivery, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return data.HookDelivery{}, err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("Error closing file: %v", err)
+		}
+	}()
+	d, err := ReadDelivery(f)
+	if err != nil {
+		return d, fmt.Errorf("%s: %w", path, err)
+	}
+	return d, nil
+}
+
+func header(d data.HookDelivery, name string) string {
+	for k, v := range d.Request.Headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,146 @@
+package relay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+	"github.com/katiem0/gh-organization-webhooks/pkg/signature"
+)
+
+const recordedDelivery = `{
+  "id": 12345678,
+  "guid": "0b989ba4-242f-11e5-81e1-c7b6966d2516",
+  "delivered_at": "2024-05-01T10:00:00Z",
+  "status": "OK",
+  "status_code": 200,
+  "event": "issues",
+  "action": "opened",
+  "request": {
+    "headers": {
+      "Content-Type": "application/json",
+      "X-GitHub-Delivery": "0b989ba4-242f-11e5-81e1-c7b6966d2516",
+      "X-GitHub-Event": "issues",
+      "X-Hub-Signature-256": "sha256=production",
+      "Content-Length": "99"
+    },
+    "payload": {"action": "opened", "issue": {"number": 1}}
+  },
+  "response": {"headers": {}, "payload": "ok"}
+}`
+
+func parse(t *testing.T, raw string) data.HookDelivery {
+	t.Helper()
+	var d data.HookDelivery
+	if err := json.Unmarshal([]byte(raw), &d); err != nil {
+		t.Fatalf("Failed to parse delivery: %v", err)
+	}
+	return d
+}
+
+func TestNewRequest(t *testing.T) {
+	d := parse(t, recordedDelivery)
+	secret := []byte("local")
+
+	req, err := NewRequest(context.Background(), "http://localhost:8080/hook", d, secret)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	body, _ := io.ReadAll(req.Body)
+	if string(body) != `{"action":"opened","issue":{"number":1}}` {
+		t.Errorf("Unexpected body %s", body)
+	}
+	if err := signature.VerifyHeaders(secret, body, req.Header); err != nil {
+		t.Errorf("Expected request to be re-signed, got %v", err)
+	}
+	if got := req.Header.Get("X-GitHub-Event"); got != "issues" {
+		t.Errorf("Expected event header to be replayed, got %q", got)
+	}
+	if got := req.Header.Get("Content-Length"); got != "" {
+		t.Errorf("Expected recorded Content-Length to be dropped, got %q", got)
+	}
+
+	req, _ = NewRequest(context.Background(), "http://localhost:8080/hook", d, nil)
+	if got := req.Header.Get(signature.HeaderSHA256); got != "" {
+		t.Errorf("Expected recorded signature to be dropped without a secret, got %q", got)
+	}
+}
+
+func TestPayloadForm(t *testing.T) {
+	d := parse(t, recordedDelivery)
+	d.Request.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+
+	values, err := url.ParseQuery(string(Payload(d)))
+	if err != nil {
+		t.Fatalf("Failed to parse form payload: %v", err)
+	}
+	if got := values.Get("payload"); got != `{"action":"opened","issue":{"number":1}}` {
+		t.Errorf("Unexpected form payload %s", got)
+	}
+
+	d.Request.Payload = json.RawMessage("null")
+	if got := Payload(d); got != nil {
+		t.Errorf("Expected empty payload, got %s", got)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	result, err := Send(context.Background(), server.Client(), server.URL, parse(t, recordedDelivery), []byte("local"))
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if result.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", result.StatusCode)
+	}
+	if received.Get("X-GitHub-Delivery") != "0b989ba4-242f-11e5-81e1-c7b6966d2516" {
+		t.Errorf("Expected delivery header to be replayed, got %v", received)
+	}
+}
+
+func TestLoadDelivery(t *testing.T) {
+	dir := t.TempDir()
+
+	plain := filepath.Join(dir, "delivery.json")
+	if err := os.WriteFile(plain, []byte(recordedDelivery), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, _ = gz.Write([]byte(recordedDelivery))
+	_ = gz.Close()
+	compressed := filepath.Join(dir, "delivery.json.gz")
+	if err := os.WriteFile(compressed, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{plain, compressed} {
+		d, err := LoadDelivery(path)
+		if err != nil {
+			t.Fatalf("LoadDelivery(%s) error = %v", path, err)
+		}
+		if d.ID != 12345678 || d.Event != "issues" {
+			t.Errorf("LoadDelivery(%s) = %+v", path, d)
+		}
+	}
+
+	if _, err := LoadDelivery(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
@@ -0,0 +1,198 @@
+// Package snapshot saves the webhook configuration of an organization to a
+// versioned JSON file and plans how to restore it.
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+)
+
+// Kind identifies a snapshot file.
+const Kind = "organization-webhooks-snapshot"
+
+// SchemaVersion is the version of the snapshot format written by this build.
+// Snapshots with a newer version are refused rather than partly restored.
+const SchemaVersion = 1
+
+// RedactedSecret is how GitHub returns the secret of a webhook that has one.
+const RedactedSecret = "********"
+
+// Snapshot is the webhook configuration of an organization at a point in time.
+type Snapshot struct {
+	Kind          string         `json:"kind"`
+	SchemaVersion int            `json:"schema_version"`
+	ToolVersion   string         `json:"tool_version"`
+	Hostname      string         `json:"hostname"`
+	Organization  string         `json:"organization"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Webhooks      []data.Webhook `json:"webhooks"`
+}
+
+// New returns a snapshot of the organization's webhooks taken now.
+func New(hostname, owner, toolVersion string, webhooks []data.Webhook) *Snapshot {
+	if webhooks == nil {
+		webhooks = []data.Webhook{}
+	}
+	return &Snapshot{
+		Kind:          Kind,
+		SchemaVersion: SchemaVersion,
+		ToolVersion:   toolVersion,
+		Hostname:      hostname,
+		Organization:  owner,
+		CreatedAt:     time.Now().UTC(),
+		Webhooks:      webhooks,
+	}
+}
+
+// Write writes the snapshot as indented JSON.
+func (s *Snapshot) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// Load reads a snapshot file.
+func Load(path string) (*Snapshot, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read snapshot: %w", err)
+	}
+	return Parse(b)
+}
+
+// Parse parses a snapshot, checking that it is one this build can restore.
+func Parse(b []byte) (*Snapshot, error) {
+	var s Snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("unable to parse snapshot: %w", err)
+	}
+	if s.Kind != Kind {
+		return nil, fmt.Errorf("not a webhook snapshot (kind %q)", s.Kind)
+	}
+	if s.SchemaVersion < 1 || s.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("snapshot schema version %d is not supported, upgrade the extension to restore it", s.SchemaVersion)
+	}
+	return &s, nil
+}
+
+// Actions in a restore plan.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+)
+
+// Change is a step of a restore plan.
+type Change struct {
+	Action string
+	// Webhook is the configuration from the snapshot
+	Webhook data.Webhook
+	// Current is the webhook in the organization that is updated
+	Current data.Webhook
+	// Differences lists the fields that differ from the snapshot
+	Differences []string
+}
+
+// UpdatesHook reports whether the change updates the webhook's active state
+// or events. Organization webhooks are always named "web", so the name is
+// never updated.
+func (c Change) UpdatesHook() bool {
+	for _, d := range c.Differences {
+		if d == "active" || d == "events" {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdatesConfig reports whether the change updates the webhook's configuration.
+func (c Change) UpdatesConfig() bool {
+	for _, d := range c.Differences {
+		if strings.HasPrefix(d, "config.") {
+			return true
+		}
+	}
+	return false
+}
+
+// Plan compares the snapshot to the current webhooks of an organization and
+// returns the webhooks to recreate and to revert, along with the current
+// webhooks that aren't in the snapshot, which are left alone. Webhooks are
+// matched by ID when restoring into the organization the snapshot was taken
+// from, and otherwise by URL.
+func (s *Snapshot) Plan(owner string, current []data.Webhook) (changes []Change, extra []data.Webhook) {
+	sameOrg := strings.EqualFold(owner, s.Organization)
+	matched := map[int]bool{}
+
+	for _, want := range s.Webhooks {
+		have, ok := match(want, current, sameOrg, matched)
+		if !ok {
+			changes = append(changes, Change{Action: ActionCreate, Webhook: want})
+			continue
+		}
+		matched[have.ID] = true
+		if diff := differences(want, have); len(diff) > 0 {
+			changes = append(changes, Change{Action: ActionUpdate, Webhook: want, Current: have, Differences: diff})
+		}
+	}
+
+	for _, have := range current {
+		if !matched[have.ID] {
+			extra = append(extra, have)
+		}
+	}
+	return changes, extra
+}
+
+func match(want data.Webhook, current []data.Webhook, sameOrg bool, matched map[int]bool) (data.Webhook, bool) {
+	if sameOrg {
+		for _, have := range current {
+			if have.ID == want.ID && !matched[have.ID] {
+				return have, true
+			}
+		}
+	}
+	for _, have := range current {
+		if have.Config.Url == want.Config.Url && !matched[have.ID] {
+			return have, true
+		}
+	}
+	return data.Webhook{}, false
+}
+
+// differences lists the restorable fields of have that differ from want. The
+// secret can't be compared as GitHub only returns it redacted.
+func differences(want, have data.Webhook) []string {
+	var diff []string
+	if want.Active != have.Active {
+		diff = append(diff, "active")
+	}
+	if !sameEvents(want.Events, have.Events) {
+		diff = append(diff, "events")
+	}
+	if want.Config.Url != have.Config.Url {
+		diff = append(diff, "config.url")
+	}
+	if want.Config.ContentType != have.Config.ContentType {
+		diff = append(diff, "config.content_type")
+	}
+	if want.Config.InsecureSSL != have.Config.InsecureSSL {
+		diff = append(diff, "config.insecure_ssl")
+	}
+	return diff
+}
+
+func sameEvents(a, b []string) bool {
+	a = slices.Clone(a)
+	b = slices.Clone(b)
+	sort.Strings(a)
+	sort.Strings(b)
+	return slices.Equal(a, b)
+}
@@ -0,0 +1,104 @@
+package snapshot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-organization-webhooks/internal/data"
+)
+
+func hook(id int, url string, events ...string) data.Webhook {
+	return data.Webhook{
+		HookType: "Organization",
+		ID:       id,
+		Name:     "web",
+		Active:   true,
+		Events:   events,
+		Config:   data.Config{ContentType: "json", InsecureSSL: "0", Secret: RedactedSecret, Url: url},
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	s := New("github.com", "my-org", "v1.2.3", []data.Webhook{hook(1, "https://ci.example.com/github", "push")})
+
+	var b bytes.Buffer
+	if err := s.Write(&b); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	for _, field := range []string{`"kind": "organization-webhooks-snapshot"`, `"schema_version": 1`, `"tool_version": "v1.2.3"`, `"hostname": "github.com"`, `"organization": "my-org"`, `"created_at"`} {
+		if !strings.Contains(b.String(), field) {
+			t.Errorf("Expected snapshot to contain %s, got %s", field, b.String())
+		}
+	}
+
+	parsed, err := Parse(b.Bytes())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if parsed.Organization != "my-org" || len(parsed.Webhooks) != 1 || parsed.Webhooks[0].Config.Url != "https://ci.example.com/github" {
+		t.Errorf("Unexpected snapshot %+v", parsed)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := map[string]string{
+		"not json":       `webhooks`,
+		"wrong kind":     `{"kind":"something-else","schema_version":1}`,
+		"newer version":  `{"kind":"organization-webhooks-snapshot","schema_version":99}`,
+		"missing schema": `{"kind":"organization-webhooks-snapshot"}`,
+	}
+	for name, input := range tests {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("%s: expected error, got nil", name)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	s := New("github.com", "my-org", "v1.2.3", []data.Webhook{
+		hook(1, "https://ci.example.com/github", "push"),
+		hook(2, "https://deploy.example.com/github", "deployment"),
+		hook(3, "https://chat.example.com/github", "issues", "pull_request"),
+	})
+
+	changed := hook(2, "https://deploy.example.com/github", "deployment", "push")
+	changed.Active = false
+	changed.Config.InsecureSSL = "1"
+	reordered := hook(3, "https://chat.example.com/github", "pull_request", "issues")
+	extra := hook(9, "https://unknown.example.com/github", "push")
+
+	changes, extras := s.Plan("my-org", []data.Webhook{changed, reordered, extra})
+
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %+v", changes)
+	}
+	if changes[0].Action != ActionCreate || changes[0].Webhook.ID != 1 {
+		t.Errorf("Expected deleted webhook 1 to be recreated, got %+v", changes[0])
+	}
+	update := changes[1]
+	if update.Action != ActionUpdate || update.Current.ID != 2 || strings.Join(update.Differences, ",") != "active,events,config.insecure_ssl" {
+		t.Errorf("Expected changed webhook 2 to be reverted, got %+v", update)
+	}
+	if !update.UpdatesHook() || !update.UpdatesConfig() {
+		t.Errorf("Expected both the hook and its config to be updated, got %+v", update)
+	}
+	if len(extras) != 1 || extras[0].ID != 9 {
+		t.Errorf("Expected webhook 9 to be left alone, got %+v", extras)
+	}
+}
+
+func TestPlanOtherOrganization(t *testing.T) {
+	s := New("github.com", "my-org", "v1.2.3", []data.Webhook{hook(1, "https://ci.example.com/github", "push")})
+
+	// IDs from another organization mean nothing, so webhooks are matched by URL
+	changes, _ := s.Plan("other-org", []data.Webhook{hook(1, "https://other.example.com/github", "push")})
+	if len(changes) != 1 || changes[0].Action != ActionCreate {
+		t.Errorf("Expected webhook to be created in the other organization, got %+v", changes)
+	}
+
+	changes, _ = s.Plan("other-org", []data.Webhook{hook(7, "https://ci.example.com/github", "push")})
+	if len(changes) != 0 {
+		t.Errorf("Expected matching webhook to be left alone, got %+v", changes)
+	}
+}
@@ -0,0 +1,29 @@
+// Package version reports the version of the extension.
+package version
+
+import "runtime/debug"
+
+// Version is set at build time with
+// -ldflags "-X github.com/katiem0/gh-organization-webhooks/internal/version.Version=v1.2.3".
+// When it isn't set the module version or VCS revision from the build info is used.
+var Version = ""
+
+// String returns the version of the running binary, or "dev" when unknown.
+func String() string {
+	if Version != "" {
+		return Version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && len(setting.Value) >= 12 {
+			return "dev-" + setting.Value[:12]
+		}
+	}
+	return "dev"
+}
@@ -8,8 +8,8 @@ import (
 
 func main() {
 	// Instantiate and execute root command
-	cmd := cmd.NewCmd()
-	if err := cmd.Execute(); err != nil {
-		os.Exit(1)
+	root := cmd.NewCmd()
+	if err := root.Execute(); err != nil {
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -0,0 +1,137 @@
+// Package signature computes and verifies the signatures GitHub sends with
+// webhook deliveries in the X-Hub-Signature and X-Hub-Signature-256 headers.
+//
+// Signatures are an HMAC of the raw request body keyed with the webhook
+// secret, hex encoded and prefixed with the algorithm, for example
+// "sha256=757107ea...". Verification uses a constant-time comparison.
+package signature
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // X-Hub-Signature is defined by GitHub as HMAC-SHA1
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// HeaderSHA1 is the legacy header carrying the HMAC-SHA1 signature.
+	HeaderSHA1 = "X-Hub-Signature"
+	// HeaderSHA256 is the header carrying the HMAC-SHA256 signature.
+	HeaderSHA256 = "X-Hub-Signature-256"
+
+	prefixSHA1   = "sha1="
+	prefixSHA256 = "sha256="
+)
+
+var (
+	// ErrMissingSignature is returned when a delivery has no signature header.
+	ErrMissingSignature = errors.New("missing webhook signature")
+	// ErrInvalidSignature is returned when a signature does not match the payload.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrUnsupportedAlgorithm is returned for signatures not prefixed with sha1= or sha256=.
+	ErrUnsupportedAlgorithm = errors.New("unsupported webhook signature algorithm")
+)
+
+// SHA256 returns the X-Hub-Signature-256 header value for the payload.
+func SHA256(secret, payload []byte) string {
+	return prefixSHA256 + hex.EncodeToString(sum(sha256.New, secret, payload))
+}
+
+// SHA1 returns the X-Hub-Signature header value for the payload.
+func SHA1(secret, payload []byte) string {
+	return prefixSHA1 + hex.EncodeToString(sum(sha1.New, secret, payload))
+}
+
+// Verify checks a signature header value of the form sha256=<hex> or
+// sha1=<hex> against the payload. When the header the value came from is
+// known, use VerifySHA256 or VerifySHA1 so the algorithm can't be swapped.
+func Verify(secret, payload []byte, signature string) error {
+	switch {
+	case signature == "":
+		return ErrMissingSignature
+	case strings.HasPrefix(signature, prefixSHA256):
+		return VerifySHA256(secret, payload, signature)
+	case strings.HasPrefix(signature, prefixSHA1):
+		return VerifySHA1(secret, payload, signature)
+	default:
+		return ErrUnsupportedAlgorithm
+	}
+}
+
+// VerifySHA256 checks an X-Hub-Signature-256 header value, which must be of
+// the form sha256=<hex>, against the payload.
+func VerifySHA256(secret, payload []byte, signature string) error {
+	return verify(sha256.New, prefixSHA256, secret, payload, signature)
+}
+
+// VerifySHA1 checks an X-Hub-Signature header value, which must be of the
+// form sha1=<hex>, against the payload.
+func VerifySHA1(secret, payload []byte, signature string) error {
+	return verify(sha1.New, prefixSHA1, secret, payload, signature)
+}
+
+func verify(newHash func() hash.Hash, prefix string, secret, payload []byte, signature string) error {
+	if signature == "" {
+		return ErrMissingSignature
+	}
+	digest, ok := strings.CutPrefix(signature, prefix)
+	if !ok {
+		return ErrUnsupportedAlgorithm
+	}
+	got, err := hex.DecodeString(digest)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal(got, sum(newHash, secret, payload)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// VerifyHeaders verifies the payload against the X-Hub-Signature-256 header.
+func VerifyHeaders(secret, payload []byte, header http.Header) error {
+	return VerifySHA256(secret, payload, header.Get(HeaderSHA256))
+}
+
+// VerifyHeadersAllowSHA1 verifies the payload against the X-Hub-Signature-256
+// header, falling back to the legacy X-Hub-Signature header only when the
+// SHA-256 header is absent, as it is for deliveries from some older GitHub
+// Enterprise Server versions.
+func VerifyHeadersAllowSHA1(secret, payload []byte, header http.Header) error {
+	if signature := header.Get(HeaderSHA256); signature != "" {
+		return VerifySHA256(secret, payload, signature)
+	}
+	return VerifySHA1(secret, payload, header.Get(HeaderSHA1))
+}
+
+// VerifyRequest reads the request body, verifies its X-Hub-Signature-256
+// header and returns the body. The request body is replaced so it can be read again.
+func VerifyRequest(r *http.Request, secret []byte) ([]byte, error) {
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(payload))
+	if err := VerifyHeaders(secret, payload, r.Header); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// SetHeaders signs the payload and sets both signature headers.
+func SetHeaders(header http.Header, secret, payload []byte) {
+	header.Set(HeaderSHA256, SHA256(secret, payload))
+	header.Set(HeaderSHA1, SHA1(secret, payload))
+}
+
+func sum(newHash func() hash.Hash, secret, payload []byte) []byte {
+	mac := hmac.New(newHash, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
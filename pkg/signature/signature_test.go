@@ -0,0 +1,150 @@
+package signature
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test vectors from GitHub's "Validating webhook deliveries" documentation,
+// with the SHA-1 value computed for the same secret and payload.
+var vectors = []struct {
+	secret  string
+	payload string
+	sha256  string
+	sha1    string
+}{
+	{
+		secret:  "It's a Secret to Everybody",
+		payload: "Hello, World!",
+		sha256:  "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17",
+		sha1:    "sha1=01dc10d0c83e72ed246219cdd91669667fe2ca59",
+	},
+	{
+		secret:  "",
+		payload: "",
+		sha256:  "sha256=b613679a0814d9ec772f95d778c35fc5ff1697c493715653c6c712144292c5ad",
+		sha1:    "sha1=fbdb1d1b18aa6c08324b7d64b71fb76370690e1d",
+	},
+}
+
+func TestSign(t *testing.T) {
+	for _, v := range vectors {
+		if got := SHA256([]byte(v.secret), []byte(v.payload)); got != v.sha256 {
+			t.Errorf("SHA256(%q, %q) = %s, want %s", v.secret, v.payload, got, v.sha256)
+		}
+		if got := SHA1([]byte(v.secret), []byte(v.payload)); got != v.sha1 {
+			t.Errorf("SHA1(%q, %q) = %s, want %s", v.secret, v.payload, got, v.sha1)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	for _, v := range vectors {
+		for _, sig := range []string{v.sha256, v.sha1} {
+			if err := Verify([]byte(v.secret), []byte(v.payload), sig); err != nil {
+				t.Errorf("Verify(%s) returned %v", sig, err)
+			}
+		}
+	}
+
+	secret, payload := []byte(vectors[0].secret), []byte(vectors[0].payload)
+	tests := []struct {
+		signature string
+		want      error
+	}{
+		{"", ErrMissingSignature},
+		{"md5=abc", ErrUnsupportedAlgorithm},
+		{"sha256=not-hex", ErrInvalidSignature},
+		{vectors[1].sha256, ErrInvalidSignature},
+		{strings.ToUpper(vectors[0].sha256[:7]) + vectors[0].sha256[7:], ErrUnsupportedAlgorithm},
+	}
+	for _, tt := range tests {
+		if err := Verify(secret, payload, tt.signature); !errors.Is(err, tt.want) {
+			t.Errorf("Verify(%q) = %v, want %v", tt.signature, err, tt.want)
+		}
+	}
+
+	if err := Verify([]byte("wrong"), payload, vectors[0].sha256); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected invalid signature for wrong secret, got %v", err)
+	}
+}
+
+func TestVerifyHeaders(t *testing.T) {
+	secret, payload := []byte(vectors[0].secret), []byte(vectors[0].payload)
+
+	header := http.Header{}
+	SetHeaders(header, secret, payload)
+	if err := VerifyHeaders(secret, payload, header); err != nil {
+		t.Errorf("Expected signed headers to verify, got %v", err)
+	}
+
+	// A valid SHA-1 signature does not rescue an invalid SHA-256 signature
+	header.Set(HeaderSHA256, vectors[1].sha256)
+	if err := VerifyHeaders(secret, payload, header); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected invalid signature, got %v", err)
+	}
+
+	// The SHA-1 header is not used unless allowed
+	header.Del(HeaderSHA256)
+	if err := VerifyHeaders(secret, payload, header); !errors.Is(err, ErrMissingSignature) {
+		t.Errorf("Expected missing signature without the SHA-256 header, got %v", err)
+	}
+
+	// Each header only accepts its own algorithm
+	header.Set(HeaderSHA256, vectors[0].sha1)
+	if err := VerifyHeaders(secret, payload, header); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("Expected a SHA-1 signature in the SHA-256 header to be rejected, got %v", err)
+	}
+
+	if err := VerifyHeaders(secret, payload, http.Header{}); !errors.Is(err, ErrMissingSignature) {
+		t.Errorf("Expected missing signature, got %v", err)
+	}
+}
+
+func TestVerifyHeadersAllowSHA1(t *testing.T) {
+	secret, payload := []byte(vectors[0].secret), []byte(vectors[0].payload)
+
+	header := http.Header{}
+	header.Set(HeaderSHA1, vectors[0].sha1)
+	if err := VerifyHeadersAllowSHA1(secret, payload, header); err != nil {
+		t.Errorf("Expected fallback to SHA-1 signature, got %v", err)
+	}
+
+	header.Set(HeaderSHA1, vectors[0].sha256)
+	if err := VerifyHeadersAllowSHA1(secret, payload, header); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("Expected a SHA-256 signature in the SHA-1 header to be rejected, got %v", err)
+	}
+
+	// A valid SHA-1 signature does not rescue an invalid SHA-256 signature
+	header.Set(HeaderSHA1, vectors[0].sha1)
+	header.Set(HeaderSHA256, vectors[1].sha256)
+	if err := VerifyHeadersAllowSHA1(secret, payload, header); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected invalid signature, got %v", err)
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	secret, payload := []byte(vectors[0].secret), vectors[0].payload
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+	req.Header.Set(HeaderSHA256, vectors[0].sha256)
+
+	body, err := VerifyRequest(req, secret)
+	if err != nil || string(body) != payload {
+		t.Fatalf("VerifyRequest() = %q, %v", body, err)
+	}
+
+	again, _ := io.ReadAll(req.Body)
+	if string(again) != payload {
+		t.Errorf("Expected body to be readable again, got %q", again)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+	if _, err := VerifyRequest(req, secret); !errors.Is(err, ErrMissingSignature) {
+		t.Errorf("Expected missing signature, got %v", err)
+	}
+}